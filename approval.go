@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+)
+
+// Possible values of the approval result output.
+const (
+	approvalApproved = "approved"
+	approvalRejected = "rejected"
+	approvalTimedOut = "timed_out"
+)
+
+// approvalResultOutputKey is the output exporting the approval result.
+const approvalResultOutputKey = "SLACK_APPROVAL_RESULT"
+
+// approvalPollInterval is the time to wait between two checks of the message.
+var approvalPollInterval = 10 * time.Second
+
+// approval is the decision made on an approval message.
+type approval struct {
+	// Result is one of approved, rejected or timed_out.
+	Result string
+
+	// User is the ID of the user who made the decision.
+	User string
+}
+
+// parseList splits s at newlines and commas, dropping the empty items.
+func parseList(s string) []string {
+	var items []string
+	for _, item := range strings.FieldsFunc(s, func(r rune) bool { return r == '\n' || r == ',' }) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// isApprover tells whether the given user is allowed to approve or reject.
+func isApprover(conf config, user string) bool {
+	for _, approver := range conf.ApprovalUsers {
+		if approver == user {
+			return true
+		}
+	}
+	return false
+}
+
+// checkReactions looks for an approve or reject reaction added by an approver.
+func checkReactions(conf config, channel, ts string) (*approval, error) {
+	var response struct {
+		slack.APIResponse
+		Message struct {
+			Reactions []struct {
+				Name  string   `json:"name"`
+				Users []string `json:"users"`
+			} `json:"reactions"`
+		} `json:"message"`
+	}
+	params := url.Values{"channel": {channel}, "timestamp": {ts}, "full": {"true"}}
+	if err := callAPI(conf.APIToken, "reactions.get", params, &response); err != nil {
+		return nil, err
+	}
+
+	for _, reaction := range response.Message.Reactions {
+		var result string
+		switch reaction.Name {
+		case conf.RejectReaction:
+			result = approvalRejected
+		case conf.ApproveReaction:
+			result = approvalApproved
+		default:
+			continue
+		}
+		for _, user := range reaction.Users {
+			if isApprover(conf, user) {
+				return &approval{Result: result, User: user}, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// checkReplies looks for a thread reply from an approver consisting of the approve or reject keyword.
+func checkReplies(conf config, channel, ts string) (*approval, error) {
+	var response struct {
+		slack.APIResponse
+		Messages []struct {
+			User string `json:"user"`
+			Text string `json:"text"`
+			Ts   string `json:"ts"`
+		} `json:"messages"`
+	}
+	params := url.Values{"channel": {channel}, "ts": {ts}}
+	if err := callAPI(conf.APIToken, "conversations.replies", params, &response); err != nil {
+		return nil, err
+	}
+
+	for _, reply := range response.Messages {
+		if reply.Ts == ts || !isApprover(conf, reply.User) {
+			continue
+		}
+		text := strings.TrimSpace(reply.Text)
+		switch {
+		case conf.RejectKeyword != "" && strings.EqualFold(text, conf.RejectKeyword):
+			return &approval{Result: approvalRejected, User: reply.User}, nil
+		case conf.ApproveKeyword != "" && strings.EqualFold(text, conf.ApproveKeyword):
+			return &approval{Result: approvalApproved, User: reply.User}, nil
+		}
+	}
+	return nil, nil
+}
+
+// waitForApproval polls the reactions and thread replies of the sent message
+// until an approver approves or rejects it, or the approval timeout expires.
+func waitForApproval(conf config, channel, ts string) (approval, error) {
+	log.Infof("Waiting for approval (timeout: %s)", conf.ApprovalTimeout)
+
+	deadline := time.Now().Add(conf.ApprovalTimeout)
+	for {
+		decision, err := checkReactions(conf, channel, ts)
+		if err != nil {
+			return approval{}, fmt.Errorf("failed to get reactions: %s", err)
+		}
+		if decision == nil && (conf.ApproveKeyword != "" || conf.RejectKeyword != "") {
+			if decision, err = checkReplies(conf, channel, ts); err != nil {
+				return approval{}, fmt.Errorf("failed to get thread replies: %s", err)
+			}
+		}
+		if decision != nil {
+			return *decision, nil
+		}
+
+		if time.Now().Add(approvalPollInterval).After(deadline) {
+			return approval{Result: approvalTimedOut}, nil
+		}
+		log.Debugf("No decision yet, checking again in %s", approvalPollInterval)
+		time.Sleep(approvalPollInterval)
+	}
+}
+
+// requireApproval waits for the decision on the sent message, exports it
+// and returns an error unless the message got approved.
+func requireApproval(conf config, response slack.Response) error {
+	decision, err := waitForApproval(conf, response.Channel, response.Timestamp)
+	if err != nil {
+		return err
+	}
+
+	if err := exportEnvVariable(approvalResultOutputKey, decision.Result); err != nil {
+		return fmt.Errorf("failed to export outputs: %s", err)
+	}
+
+	switch decision.Result {
+	case approvalApproved:
+		log.Donef("Approved by %s", decision.User)
+		return nil
+	case approvalRejected:
+		return fmt.Errorf("rejected by %s", decision.User)
+	default:
+		return fmt.Errorf("no decision was made within %s", conf.ApprovalTimeout)
+	}
+}
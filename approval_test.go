@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRequest is a Web API call received by the fake Slack server.
+type fakeRequest struct {
+	Method string
+	Body   string
+}
+
+// newFakeSlack starts a Slack Web API stand-in answering the given methods
+// with the given JSON bodies. A "method#n" key overrides the answer to the n-th call of the method.
+// It returns the received calls in order.
+func newFakeSlack(t *testing.T, responses map[string]string) *[]fakeRequest {
+	var mu sync.Mutex
+	var requests []fakeRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		method := r.URL.Path[1:]
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, fakeRequest{Method: method, Body: string(body)})
+
+		calls := 0
+		for _, req := range requests {
+			if req.Method == method {
+				calls++
+			}
+		}
+		response, ok := responses[fmt.Sprintf("%s#%d", method, calls)]
+		if !ok {
+			response, ok = responses[method]
+		}
+		if !ok {
+			response = `{"ok": false, "error": "unknown_method"}`
+		}
+		fmt.Fprint(w, response)
+	}))
+	t.Cleanup(server.Close)
+
+	url := slackAPIURL
+	t.Cleanup(func() { slackAPIURL = url })
+	slackAPIURL = server.URL
+	return &requests
+}
+
+func Test_waitForApproval(t *testing.T) {
+	interval := approvalPollInterval
+	t.Cleanup(func() { approvalPollInterval = interval })
+	approvalPollInterval = time.Millisecond
+
+	conf := config{
+		APIToken:        "token",
+		ApprovalTimeout: 10 * time.Millisecond,
+		ApprovalUsers:   []string{"U1"},
+		ApproveReaction: "white_check_mark",
+		RejectReaction:  "x",
+		ApproveKeyword:  "approve",
+		RejectKeyword:   "reject",
+	}
+
+	tests := []struct {
+		name      string
+		reactions string
+		replies   string
+		want      approval
+	}{
+		{
+			name:      "Approved by reaction",
+			reactions: `[{"name": "white_check_mark", "users": ["U2", "U1"]}]`,
+			replies:   `[]`,
+			want:      approval{Result: approvalApproved, User: "U1"},
+		},
+		{
+			name:      "Rejected by thread reply",
+			reactions: `[{"name": "x", "users": ["U2"]}]`,
+			replies:   `[{"user": "U1", "text": "1405894322.002768", "ts": "1405894322.002768"}, {"user": "U1", "text": " Reject ", "ts": "1405894323.000001"}]`,
+			want:      approval{Result: approvalRejected, User: "U1"},
+		},
+		{
+			name:      "Timed out",
+			reactions: `[]`,
+			replies:   `[{"user": "U2", "text": "approve", "ts": "1405894323.000001"}]`,
+			want:      approval{Result: approvalTimedOut},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newFakeSlack(t, map[string]string{
+				"reactions.get":         fmt.Sprintf(`{"ok": true, "message": {"reactions": %s}}`, tt.reactions),
+				"conversations.replies": fmt.Sprintf(`{"ok": true, "messages": %s}`, tt.replies),
+			})
+
+			got, err := waitForApproval(conf, "C024BE91L", "1405894322.002768")
+			if err != nil {
+				t.Fatalf("waitForApproval() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("waitForApproval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
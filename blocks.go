@@ -0,0 +1,292 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+)
+
+// Layouts of the message.
+const (
+	// layoutAttachment sends the attachment as it is.
+	layoutAttachment = "attachment"
+
+	// layoutBlocks converts the attachment to Block Kit blocks.
+	layoutBlocks = "blocks"
+)
+
+// Limits of Block Kit blocks.
+// See also: https://api.slack.com/reference/block-kit/blocks
+const (
+	blockHeaderLimit       = 150
+	blockSectionFieldLimit = 10
+	blockContextLimit      = 10
+	blockActionLimit       = 25
+	blockButtonTextLimit   = 75
+)
+
+// block is a Block Kit layout block.
+type block struct {
+	Type      string        `json:"type"`
+	Text      *blockText    `json:"text,omitempty"`
+	Fields    []blockText   `json:"fields,omitempty"`
+	Accessory *blockImage   `json:"accessory,omitempty"`
+	Elements  []interface{} `json:"elements,omitempty"`
+	ImageURL  string        `json:"image_url,omitempty"`
+	AltText   string        `json:"alt_text,omitempty"`
+}
+
+// blockText is a plain_text or mrkdwn text object.
+type blockText struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	Emoji bool   `json:"emoji,omitempty"`
+}
+
+// blockImage is an image element of a section or a context block.
+type blockImage struct {
+	Type     string `json:"type"`
+	ImageURL string `json:"image_url"`
+	AltText  string `json:"alt_text"`
+}
+
+// blockButton is a button element opening a URL.
+type blockButton struct {
+	Type string    `json:"type"`
+	Text blockText `json:"text"`
+	URL  string    `json:"url"`
+}
+
+// blockBuilder holds the high-level Block Kit inputs.
+type blockBuilder struct {
+	Header   string
+	Text     string
+	Fields   string
+	Context  string
+	ImageURL string
+	Buttons  string
+}
+
+// isEmpty tells whether none of the Block Kit inputs are set.
+func (b blockBuilder) isEmpty() bool {
+	return b == blockBuilder{}
+}
+
+// override replaces the values of b with the non-empty values of o.
+func (b blockBuilder) override(o blockBuilder) blockBuilder {
+	for _, v := range []struct {
+		dst *string
+		src string
+	}{
+		{&b.Header, o.Header},
+		{&b.Text, o.Text},
+		{&b.Fields, o.Fields},
+		{&b.Context, o.Context},
+		{&b.ImageURL, o.ImageURL},
+		{&b.Buttons, o.Buttons},
+	} {
+		if v.src != "" {
+			*v.dst = v.src
+		}
+	}
+	return b
+}
+
+// build assembles the blocks: header, section text, sections of the field pairs, image, context and actions.
+func (b blockBuilder) build() []block {
+	var blocks []block
+	if header := strings.TrimSpace(b.Header); header != "" {
+		blocks = append(blocks, block{Type: "header", Text: &blockText{Type: "plain_text", Text: truncate(header, blockHeaderLimit), Emoji: true}})
+	}
+	if text := strings.TrimSpace(ensureNewlines(b.Text)); text != "" {
+		blocks = append(blocks, block{Type: "section", Text: &blockText{Type: "mrkdwn", Text: text}})
+	}
+
+	var fields []blockText
+	for _, p := range pairs(b.Fields) {
+		fields = append(fields, fieldText(p[0], ensureNewlines(p[1])))
+	}
+	blocks = append(blocks, fieldSections(fields)...)
+
+	if imageURL := strings.TrimSpace(b.ImageURL); imageURL != "" {
+		altText := strings.TrimSpace(b.Header)
+		if altText == "" {
+			altText = "image"
+		}
+		blocks = append(blocks, block{Type: "image", ImageURL: imageURL, AltText: altText})
+	}
+
+	var context []interface{}
+	for _, line := range strings.Split(b.Context, "\n") {
+		if line = strings.TrimSpace(line); line != "" && len(context) < blockContextLimit {
+			context = append(context, blockText{Type: "mrkdwn", Text: line})
+		}
+	}
+	if len(context) > 0 {
+		blocks = append(blocks, block{Type: "context", Elements: context})
+	}
+
+	var buttons []blockButton
+	for _, p := range pairs(b.Buttons) {
+		buttons = append(buttons, newBlockButton(p[0], p[1]))
+	}
+	if len(buttons) > 0 {
+		blocks = append(blocks, actionsBlock(buttons))
+	}
+	return blocks
+}
+
+// fieldText formats a field as a bold title above its value.
+func fieldText(title, value string) blockText {
+	return blockText{Type: "mrkdwn", Text: "*" + title + "*\n" + value}
+}
+
+// fieldSections splits the fields into sections of at most 10 fields.
+func fieldSections(fields []blockText) []block {
+	var blocks []block
+	for len(fields) > 0 {
+		n := len(fields)
+		if n > blockSectionFieldLimit {
+			n = blockSectionFieldLimit
+		}
+		blocks = append(blocks, block{Type: "section", Fields: fields[:n]})
+		fields = fields[n:]
+	}
+	return blocks
+}
+
+// newBlockButton returns a button opening the URL.
+func newBlockButton(text, url string) blockButton {
+	return blockButton{Type: "button", Text: blockText{Type: "plain_text", Text: truncate(text, blockButtonTextLimit), Emoji: true}, URL: url}
+}
+
+// actionsBlock returns an actions block of at most 25 buttons.
+func actionsBlock(buttons []blockButton) block {
+	var elements []interface{}
+	for _, button := range buttons {
+		if len(elements) < blockActionLimit {
+			elements = append(elements, button)
+		}
+	}
+	return block{Type: "actions", Elements: elements}
+}
+
+// blocksJSON returns the blocks assembled from the Block Kit inputs as JSON.
+func (b blockBuilder) blocksJSON() string {
+	// The blocks only contain strings, encoding them can not fail.
+	data, _ := json.Marshal(b.build())
+	return string(data)
+}
+
+// attachmentBlocks converts a legacy attachment to the equivalent Block Kit blocks.
+// The colour of the attachment can not be expressed with blocks, it is kept by applyLayout if requested.
+func attachmentBlocks(a slack.Attachment) []block {
+	var blocks []block
+	if a.PreText != "" {
+		blocks = append(blocks, block{Type: "section", Text: &blockText{Type: "mrkdwn", Text: a.PreText}})
+	}
+	if a.AuthorName != "" {
+		blocks = append(blocks, block{Type: "context", Elements: []interface{}{blockText{Type: "mrkdwn", Text: a.AuthorName}}})
+	}
+
+	var text []string
+	if a.Title != "" && a.TitleLink != "" {
+		text = append(text, fmt.Sprintf("*<%s|%s>*", a.TitleLink, a.Title))
+	} else if a.Title != "" {
+		text = append(text, "*"+a.Title+"*")
+	}
+	if a.Text != "" {
+		text = append(text, a.Text)
+	}
+	var thumb *blockImage
+	if a.ThumbURL != "" {
+		thumb = &blockImage{Type: "image", ImageURL: a.ThumbURL, AltText: "thumbnail"}
+	}
+	if len(text) > 0 {
+		blocks = append(blocks, block{Type: "section", Text: &blockText{Type: "mrkdwn", Text: strings.Join(text, "\n")}, Accessory: thumb})
+	} else if thumb != nil {
+		blocks = append(blocks, block{Type: "image", ImageURL: thumb.ImageURL, AltText: thumb.AltText})
+	}
+
+	var fields []blockText
+	for _, f := range a.Fields {
+		fields = append(fields, fieldText(f.Title, f.Value))
+	}
+	blocks = append(blocks, fieldSections(fields)...)
+
+	if a.ImageURL != "" {
+		altText := a.Title
+		if altText == "" {
+			altText = "image"
+		}
+		blocks = append(blocks, block{Type: "image", ImageURL: a.ImageURL, AltText: altText})
+	}
+
+	var footer []interface{}
+	if a.FooterIcon != "" {
+		footer = append(footer, blockImage{Type: "image", ImageURL: a.FooterIcon, AltText: "footer icon"})
+	}
+	if a.Footer != "" {
+		footer = append(footer, blockText{Type: "mrkdwn", Text: a.Footer})
+	}
+	if a.TimeStamp != 0 {
+		fallback := time.Unix(int64(a.TimeStamp), 0).UTC().Format(time.RFC1123)
+		footer = append(footer, blockText{Type: "mrkdwn", Text: fmt.Sprintf("<!date^%d^{date_short_pretty} at {time}|%s>", a.TimeStamp, fallback)})
+	}
+	if len(footer) > 0 {
+		blocks = append(blocks, block{Type: "context", Elements: footer})
+	}
+
+	var buttons []blockButton
+	for _, b := range a.Buttons {
+		buttons = append(buttons, newBlockButton(b.Text, b.URL))
+	}
+	if len(buttons) > 0 {
+		blocks = append(blocks, actionsBlock(buttons))
+	}
+	return blocks
+}
+
+// applyLayout converts the attachments of the message to Block Kit blocks for the blocks layout.
+// With the colour bar the blocks are wrapped into a minimal attachment keeping only the colour,
+// otherwise they are appended to the blocks of the message and the attachments are dropped.
+func applyLayout(msg *slack.Message, layout string, colorBar bool) {
+	if layout != layoutBlocks {
+		return
+	}
+
+	var attachments []slack.Attachment
+	var blocks []json.RawMessage
+	if msg.Blocks != "" && !colorBar {
+		// The raw blocks input is validated by parseInputIntoConfig.
+		_ = json.Unmarshal([]byte(msg.Blocks), &blocks)
+	}
+	for _, a := range msg.Attachments {
+		converted := attachmentBlocks(a)
+		if len(converted) == 0 {
+			continue
+		}
+		// The blocks only contain strings, encoding them can not fail.
+		data, _ := json.Marshal(converted)
+		if colorBar {
+			attachments = append(attachments, slack.Attachment{Fallback: a.Fallback, Color: a.Color, Blocks: data})
+			continue
+		}
+
+		var raw []json.RawMessage
+		_ = json.Unmarshal(data, &raw)
+		blocks = append(blocks, raw...)
+		if msg.Text == "" {
+			msg.Text = a.Fallback
+		}
+	}
+
+	msg.Attachments = attachments
+	if !colorBar && len(blocks) > 0 {
+		data, _ := json.Marshal(blocks)
+		msg.Blocks = string(data)
+	}
+}
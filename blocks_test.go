@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+)
+
+func Test_blockBuilder_blocksJSON(t *testing.T) {
+	var manyFields []string
+	for i := 0; i < 11; i++ {
+		manyFields = append(manyFields, fmt.Sprintf("F%d|v", i))
+	}
+
+	tests := []struct {
+		name    string
+		builder blockBuilder
+		want    string
+	}{
+		{
+			name:    "Header and section text",
+			builder: blockBuilder{Header: "Build #42", Text: "line1\\nline2"},
+			want:    `[{"type":"header","text":{"type":"plain_text","text":"Build #42","emoji":true}},{"type":"section","text":{"type":"mrkdwn","text":"line1\nline2"}}]`,
+		},
+		{
+			name:    "Fields, image, context and buttons",
+			builder: blockBuilder{Fields: "App|Demo\ninvalid", ImageURL: "https://example.com/a.png", Context: "first\n\nsecond", Buttons: "Open|https://example.com"},
+			want: `[{"type":"section","fields":[{"type":"mrkdwn","text":"*App*\nDemo"}]},` +
+				`{"type":"image","image_url":"https://example.com/a.png","alt_text":"image"},` +
+				`{"type":"context","elements":[{"type":"mrkdwn","text":"first"},{"type":"mrkdwn","text":"second"}]},` +
+				`{"type":"actions","elements":[{"type":"button","text":{"type":"plain_text","text":"Open","emoji":true},"url":"https://example.com"}]}]`,
+		},
+		{
+			name:    "Fields are split into sections of 10",
+			builder: blockBuilder{Fields: strings.Join(manyFields, "\n")},
+			want: `[{"type":"section","fields":[` +
+				`{"type":"mrkdwn","text":"*F0*\nv"},{"type":"mrkdwn","text":"*F1*\nv"},{"type":"mrkdwn","text":"*F2*\nv"},{"type":"mrkdwn","text":"*F3*\nv"},{"type":"mrkdwn","text":"*F4*\nv"},` +
+				`{"type":"mrkdwn","text":"*F5*\nv"},{"type":"mrkdwn","text":"*F6*\nv"},{"type":"mrkdwn","text":"*F7*\nv"},{"type":"mrkdwn","text":"*F8*\nv"},{"type":"mrkdwn","text":"*F9*\nv"}]},` +
+				`{"type":"section","fields":[{"type":"mrkdwn","text":"*F10*\nv"}]}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.builder.blocksJSON(); got != tt.want {
+				t.Errorf("blocksJSON() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_applyLayout(t *testing.T) {
+	attachment := slack.Attachment{
+		Fallback:  "Build succeeded",
+		Color:     "#3bc3a3",
+		Title:     "Build #42",
+		TitleLink: "https://app.bitrise.io/build/1",
+		Text:      "Build succeeded",
+		ThumbURL:  "https://example.com/thumb.png",
+		Fields:    []slack.Field{{Title: "Branch", Value: "main"}},
+		Footer:    "Bitrise",
+		TimeStamp: 1700000000,
+		Buttons:   []slack.Button{{Text: "View", URL: "https://app.bitrise.io/build/1"}},
+	}
+	converted := `[{"type":"section","text":{"type":"mrkdwn","text":"*\u003chttps://app.bitrise.io/build/1|Build #42\u003e*\nBuild succeeded"},` +
+		`"accessory":{"type":"image","image_url":"https://example.com/thumb.png","alt_text":"thumbnail"}},` +
+		`{"type":"section","fields":[{"type":"mrkdwn","text":"*Branch*\nmain"}]},` +
+		`{"type":"context","elements":[{"type":"mrkdwn","text":"Bitrise"},{"type":"mrkdwn","text":"\u003c!date^1700000000^{date_short_pretty} at {time}|Tue, 14 Nov 2023 22:13:20 UTC\u003e"}]},` +
+		`{"type":"actions","elements":[{"type":"button","text":{"type":"plain_text","text":"View","emoji":true},"url":"https://app.bitrise.io/build/1"}]}]`
+
+	tests := []struct {
+		name     string
+		msg      slack.Message
+		layout   string
+		colorBar bool
+		want     slack.Message
+	}{
+		{
+			name:   "Attachment layout keeps the message",
+			msg:    slack.Message{Attachments: []slack.Attachment{attachment}},
+			layout: layoutAttachment,
+			want:   slack.Message{Attachments: []slack.Attachment{attachment}},
+		},
+		{
+			name:     "Blocks layout with colour bar",
+			msg:      slack.Message{Attachments: []slack.Attachment{attachment}},
+			layout:   layoutBlocks,
+			colorBar: true,
+			want:     slack.Message{Attachments: []slack.Attachment{{Fallback: "Build succeeded", Color: "#3bc3a3", Blocks: []byte(converted)}}},
+		},
+		{
+			name:   "Blocks layout without colour bar appends to the blocks",
+			msg:    slack.Message{Blocks: `[{"type":"divider"}]`, Attachments: []slack.Attachment{attachment}},
+			layout: layoutBlocks,
+			want:   slack.Message{Text: "Build succeeded", Blocks: `[{"type":"divider"},` + converted[1:]},
+		},
+		{
+			name:   "Empty attachment is dropped",
+			msg:    slack.Message{Text: "Hello", Attachments: []slack.Attachment{{Color: "#3bc3a3"}}},
+			layout: layoutBlocks,
+			want:   slack.Message{Text: "Hello"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := tt.msg
+			applyLayout(&msg, tt.layout, tt.colorBar)
+			if !reflect.DeepEqual(msg, tt.want) {
+				t.Errorf("applyLayout() = %+v, want %+v", msg, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+	"github.com/bitrise-tools/go-steputils/stepconf"
+)
+
+// channelIDOutputKey is the output exporting the ID of the channel the message was sent to.
+const channelIDOutputKey = "SLACK_CHANNEL_ID"
+
+// channelIDPattern matches the encoded IDs of channels, private groups and direct messages.
+var channelIDPattern = regexp.MustCompile(`^[CGD][A-Z0-9]{8,}$`)
+
+// userIDPattern matches the encoded IDs of users and Enterprise Grid users,
+// which Slack accepts as the channel to send a direct message.
+var userIDPattern = regexp.MustCompile(`^[UW][A-Z0-9]{8,}$`)
+
+// channelIDsByName caches the channels listed within the run.
+var channelIDsByName = map[string]string{}
+
+// isChannelName tells whether s is a channel name (eg. #general or general)
+// rather than a channel or user ID, a username or an email address.
+func isChannelName(s string) bool {
+	return s != "" && !channelIDPattern.MatchString(s) && !userIDPattern.MatchString(s) && !strings.HasPrefix(s, "@") && !isEmailList(s)
+}
+
+// lookupChannelID pages through the public and private channels visible to the token
+// and returns the ID of the channel with the given name.
+func lookupChannelID(token stepconf.Secret, name string) (string, error) {
+	name = strings.TrimPrefix(name, "#")
+	if id, ok := channelIDsByName[name]; ok {
+		return id, nil
+	}
+
+	cursor := ""
+	for {
+		var response struct {
+			slack.APIResponse
+			Channels []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"channels"`
+		}
+		params := url.Values{
+			"types":            {"public_channel,private_channel"},
+			"exclude_archived": {"true"},
+			"limit":            {"200"},
+		}
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+		if err := callAPI(token, "conversations.list", params, &response); err != nil {
+			return "", err
+		}
+
+		for _, channel := range response.Channels {
+			channelIDsByName[channel.Name] = channel.ID
+		}
+		if id, ok := channelIDsByName[name]; ok {
+			return id, nil
+		}
+
+		cursor = response.ResponseMetadata.NextCursor
+		if cursor == "" {
+			return "", fmt.Errorf("channel #%s not found, private channels are only visible if the bot is a member", name)
+		}
+	}
+}
+
+// channelID returns the ID of a channel given by name, other targets are returned as is.
+func channelID(token stepconf.Secret, channel string) (string, error) {
+	channel = strings.TrimSpace(channel)
+	if !isChannelName(channel) {
+		return channel, nil
+	}
+	return lookupChannelID(token, channel)
+}
+
+// resolveChannelID replaces a channel name with the channel's ID, so that updating and
+// threading work with channel names too. The name is kept if it can not be resolved.
+func resolveChannelID(c *config) {
+	if c.APIToken == "" || !isChannelName(strings.TrimSpace(c.Channel)) {
+		return
+	}
+
+	id, err := channelID(c.APIToken, c.Channel)
+	if err != nil {
+		log.Warnf("Failed to resolve the ID of channel %s, using its name: %s", c.Channel, err)
+		return
+	}
+	log.Debugf("Resolved channel %s to %s", c.Channel, id)
+	c.Channel = id
+}
+
+// joinChannel adds the bot to a public channel. Private channels can not be joined,
+// the bot has to be invited to them.
+func joinChannel(token stepconf.Secret, channel string) error {
+	id, err := channelID(token, channel)
+	if err != nil {
+		return fmt.Errorf("failed to resolve channel %s: %w", channel, err)
+	}
+
+	var info struct {
+		slack.APIResponse
+		Channel struct {
+			IsPrivate bool `json:"is_private"`
+		} `json:"channel"`
+	}
+	if err := callAPI(token, "conversations.info", url.Values{"channel": {id}}, &info); err != nil {
+		return fmt.Errorf("failed to get channel %s: %w", channel, err)
+	}
+	if info.Channel.IsPrivate {
+		return configurationErrorf("channel %s is private, the bot has to be invited with /invite before it can post there", channel)
+	}
+
+	if err := callAPI(token, "conversations.join", url.Values{"channel": {id}}, nil); err != nil {
+		return fmt.Errorf("failed to join channel %s: %w", channel, err)
+	}
+	return nil
+}
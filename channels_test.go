@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_lookupChannelID(t *testing.T) {
+	cache := channelIDsByName
+	t.Cleanup(func() { channelIDsByName = cache })
+	channelIDsByName = map[string]string{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("cursor") == "" {
+			fmt.Fprint(w, `{"ok": true, "channels": [{"id": "C01", "name": "general"}], "response_metadata": {"next_cursor": "page2"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"ok": true, "channels": [{"id": "G02", "name": "ios-builds"}], "response_metadata": {"next_cursor": ""}}`)
+	}))
+	defer server.Close()
+	defer func(u string) { slackAPIURL = u }(slackAPIURL)
+	slackAPIURL = server.URL
+
+	tests := []struct {
+		channel string
+		want    string
+		wantErr bool
+	}{
+		{channel: "#ios-builds", want: "G02"},
+		{channel: "general", want: "C01"},
+		{channel: "C024BE91L", want: "C024BE91L"},
+		{channel: "@username", want: "@username"},
+		{channel: "U024BE7LH", want: "U024BE7LH"},
+		{channel: "W012A3CDE", want: "W012A3CDE"},
+		{channel: "#missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.channel, func(t *testing.T) {
+			got, err := channelID("token", tt.channel)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("channelID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("channelID() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+// hasAPIToken tells whether any of the credentials uses the Web API.
+func hasAPIToken(c config) bool {
+	for _, cred := range c.Credentials {
+		if !cred.isWebhook() {
+			return true
+		}
+	}
+	return false
+}
+
+// hasWebhook tells whether any of the credentials delivers through an incoming webhook.
+func hasWebhook(c config) bool {
+	for _, cred := range c.Credentials {
+		if cred.isWebhook() {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCompatibility checks the resolved config against the transports of its credentials
+// before any network call is made. It rejects the combinations none of the credentials can honour
+// and warns about the ones Slack would silently ignore.
+func checkCompatibility(c config) error {
+	if !isSlackBackend(c) {
+		return checkBackendCompatibility(c)
+	}
+
+	if !hasAPIToken(c) {
+		requirements := []struct {
+			used    bool
+			feature string
+		}{
+			{len(c.EscalationRules) > 0, "Escalation rules require the API Token to read the channel history"},
+			{c.ApprovalTimeout > 0, "Approval requires the API Token to read the reactions of the message"},
+			{isEmailList(strings.TrimSpace(c.Channel)), "Sending direct messages to email addresses requires the API Token"},
+			{c.EphemeralUser != "", "Ephemeral messages require the API Token"},
+			{c.DeliveryWindow != nil || c.CancelScheduledMessageID != "", "Scheduling requires the API Token"},
+			{c.Ts != "", "Updating a message requires the API Token"},
+			{c.ThreadTsOutputVariableName != "", "Exporting the thread timestamp requires the API Token"},
+		}
+		for _, r := range requirements {
+			if r.used {
+				return configurationErrorf("%s, it can not be used with incoming webhooks or an Integration ID.", r.feature)
+			}
+		}
+	}
+
+	if c.ApprovalTimeout > 0 && len(c.ApprovalUsers) == 0 {
+		return configurationErrorf("Approval requires at least one approver, set the approval_users input.")
+	}
+
+	if c.EphemeralUser != "" && (c.Ts != "" || c.ApprovalTimeout > 0 || c.DeliveryWindow != nil || c.ThreadTsOutputVariableName != "") {
+		return configurationErrorf("Ephemeral messages can not be updated, approved, scheduled or exported as a thread, do not set the ephemeral user together with ts, approval_timeout, delivery_window or output_thread_ts.")
+	}
+
+	if c.DeliveryWindow != nil && (c.Ts != "" || c.ApprovalTimeout > 0 || c.ThreadTsOutputVariableName != "") {
+		return configurationErrorf("Scheduled messages can not be updated, approved or exported as a thread, do not set the delivery window together with ts, approval_timeout or output_thread_ts.")
+	}
+
+	if !hasWebhook(c) {
+		return nil
+	}
+
+	if feature := apiOnlyFeature(c); feature != "" && hasAPIToken(c) {
+		log.Warnf("Incoming webhooks do not support %s, they are skipped when falling back from the API Token.", feature)
+	}
+	if c.ThreadTs != "" {
+		log.Warnf("Incoming webhooks can not reply in a thread, Slack ignores thread_ts for messages sent through a webhook.")
+	}
+	if c.ReplyBroadcast && c.ThreadTs != "" {
+		log.Warnf("Incoming webhooks can not broadcast thread replies, Slack ignores reply_broadcast for messages sent through a webhook.")
+	}
+	if c.Channel != "" && !isEmailList(strings.TrimSpace(c.Channel)) {
+		log.Warnf("Incoming webhooks post to the channel they were created for, Slack ignores the channel input for messages sent through a webhook.")
+	}
+	return nil
+}
+
+// checkBackendCompatibility rejects the Slack specific features for the other backends,
+// which only support incoming webhooks.
+func checkBackendCompatibility(c config) error {
+	if c.Backend == backendEmail {
+		switch {
+		case c.Email.Host == "":
+			return configurationErrorf("The email backend requires the SMTP server, set the smtp_host input.")
+		case c.Email.From == "":
+			return configurationErrorf("The email backend requires the sender address, set the email_from input.")
+		case len(c.Email.Recipients) == 0:
+			return configurationErrorf("The email backend requires at least one recipient, set the email_recipients input.")
+		}
+	} else if webhookURL(c) == "" {
+		return configurationErrorf("The %s backend requires the webhook URL of the %s incoming webhook, set the webhook_url input.", c.Backend, c.Backend)
+	}
+
+	slackOnly := []struct {
+		used    bool
+		feature string
+	}{
+		{len(c.EscalationRules) > 0, "Escalation rules"},
+		{c.ApprovalTimeout > 0, "Approval"},
+		{isEmailList(strings.TrimSpace(c.Channel)), "Sending direct messages to email addresses"},
+		{c.EphemeralUser != "", "Ephemeral messages"},
+		{c.DeliveryWindow != nil || c.CancelScheduledMessageID != "", "Scheduling"},
+		{c.Ts != "", "Updating a message"},
+		{c.ThreadTsOutputVariableName != "", "Exporting the thread timestamp"},
+		{c.PreflightCheck, "Preflight check"},
+		{c.Preset == presetBuildReport, "The build report preset"},
+		{!c.BlockBuilder.isEmpty(), "The Block Kit builder"},
+	}
+	for _, f := range slackOnly {
+		if f.used {
+			return configurationErrorf("%s is only supported by the Slack backend, it can not be used with the %s backend.", f.feature, c.Backend)
+		}
+	}
+
+	if c.Backend == backendDiscord && strings.Trim(c.ThreadTs, "0123456789") != "" {
+		return configurationErrorf("Discord thread IDs are numeric, %s is not a valid thread ID.", c.ThreadTs)
+	}
+
+	if c.Layout == layoutBlocks {
+		log.Warnf("The %s backend does not support the blocks layout, the attachment is converted as usual.", c.Backend)
+	}
+
+	for _, cred := range c.Credentials {
+		if cred.WebhookURL == "" || c.Backend == backendEmail {
+			log.Warnf("The %s backend does not use the %s, it is ignored.", c.Backend, cred.Name)
+		}
+	}
+	return nil
+}
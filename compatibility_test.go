@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_checkCompatibility(t *testing.T) {
+	token := []credential{{Name: "API Token", APIToken: "token"}}
+	webhook := []credential{{Name: "Webhook URL", WebhookURL: "https://hooks.slack.com/services/T/B/X"}}
+	fallback := append(token, webhook...)
+
+	tests := []struct {
+		name    string
+		conf    config
+		wantErr bool
+	}{
+		{
+			name:    "Thread timestamp output with webhook",
+			conf:    config{Credentials: webhook, ThreadTsOutputVariableName: "SLACK_THREAD_TS"},
+			wantErr: true,
+		},
+		{
+			name:    "Update with webhook",
+			conf:    config{Credentials: webhook, Ts: "1405894322.002768"},
+			wantErr: true,
+		},
+		{
+			name: "Thread reply with webhook is only a warning",
+			conf: config{Credentials: webhook, Channel: "#builds", ThreadTs: "1405894322.002768"},
+		},
+		{
+			name: "Update with fallback webhook",
+			conf: config{Credentials: fallback, Ts: "1405894322.002768"},
+		},
+		{
+			name:    "Email recipients with webhook",
+			conf:    config{Credentials: webhook, Channel: "dev@example.com"},
+			wantErr: true,
+		},
+		{
+			name:    "Approval without approvers",
+			conf:    config{Credentials: token, ApprovalTimeout: time.Minute},
+			wantErr: true,
+		},
+		{
+			name:    "Scheduled update",
+			conf:    config{Credentials: token, DeliveryWindow: &deliveryWindow{}, Ts: "1405894322.002768"},
+			wantErr: true,
+		},
+		{
+			name:    "Teams backend without webhook",
+			conf:    config{Backend: backendTeams, Credentials: token},
+			wantErr: true,
+		},
+		{
+			name:    "Approval with Teams backend",
+			conf:    config{Backend: backendTeams, Credentials: fallback, ApprovalTimeout: time.Minute, ApprovalUsers: []string{"U1"}},
+			wantErr: true,
+		},
+		{
+			name:    "Block Kit builder with Discord backend",
+			conf:    config{Backend: backendDiscord, Credentials: webhook, BlockBuilder: blockBuilder{Header: "Build finished"}},
+			wantErr: true,
+		},
+		{
+			name: "Teams backend with thread reply",
+			conf: config{Backend: backendTeams, Credentials: webhook, ThreadTs: "1405894322.002768"},
+		},
+		{
+			name: "Ephemeral message with API token",
+			conf: config{Credentials: token, EphemeralUser: "U1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkCompatibility(tt.conf); (err != nil) != tt.wantErr {
+				t.Errorf("checkCompatibility() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
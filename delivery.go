@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+	"github.com/bitrise-tools/go-steputils/stepconf"
+)
+
+// credential is one way of delivering the message.
+// Exactly one of IntegrationID, APIToken and WebhookURL is set.
+type credential struct {
+	// Name identifies the credential in the logs.
+	Name string
+
+	IntegrationID string
+	APIToken      stepconf.Secret
+	WebhookURL    stepconf.Secret
+}
+
+// isWebhook tells whether the credential delivers through an incoming webhook.
+func (c credential) isWebhook() bool {
+	return c.APIToken == ""
+}
+
+// newCredentialChain returns the credentials to try in order:
+// integration, API token, webhook and the fallback webhook, skipping the empty ones.
+func newCredentialChain(integrationID string, apiToken stepconf.Secret, webhookURL, fallbackWebhookURL stepconf.Secret) []credential {
+	var chain []credential
+	if integrationID != "" {
+		chain = append(chain, credential{Name: "Workspace Slack Integration", IntegrationID: integrationID})
+	}
+	if apiToken != "" {
+		chain = append(chain, credential{Name: "API Token", APIToken: apiToken})
+	}
+	if webhookURL != "" {
+		chain = append(chain, credential{Name: "Webhook URL", WebhookURL: webhookURL})
+	}
+	if fallbackWebhookURL != "" && fallbackWebhookURL != webhookURL {
+		chain = append(chain, credential{Name: "fallback Webhook URL", WebhookURL: fallbackWebhookURL})
+	}
+	return chain
+}
+
+// apiOnlyFeature returns the name of the configured feature which can not be
+// delivered through an incoming webhook, or an empty string if there is none.
+func apiOnlyFeature(c config) string {
+	switch {
+	case c.EphemeralUser != "":
+		return "ephemeral messages"
+	case c.ApprovalTimeout > 0:
+		return "approval"
+	case c.Ts != "":
+		return "updating a message"
+	case c.ThreadTsOutputVariableName != "":
+		return "exporting the thread timestamp"
+	}
+	return ""
+}
+
+// downgradeForWebhook drops the message properties incoming webhooks do not support.
+func downgradeForWebhook(msg slack.Message) slack.Message {
+	if msg.PostAt != 0 {
+		log.Warnf("Incoming webhooks can not schedule messages, sending the message right away")
+		msg.PostAt = 0
+	}
+	msg.Metadata = nil
+	return msg
+}
+
+// prepare returns the config and message to deliver with the given credential.
+func prepare(conf config, msg slack.Message, cred credential) (config, slack.Message, error) {
+	if !cred.isWebhook() {
+		conf.WebhookURL = ""
+		conf.APIToken = cred.APIToken
+		return conf, msg, nil
+	}
+
+	webhookURL := string(cred.WebhookURL)
+	if cred.IntegrationID != "" {
+		var err error
+		if webhookURL, err = getWebhookURL(conf.BuildURL, cred.IntegrationID, string(conf.BuildAPIToken)); err != nil {
+			return conf, msg, err
+		}
+	}
+	conf.WebhookURL = webhookURL
+	conf.APIToken = ""
+	return conf, downgradeForWebhook(msg), nil
+}
+
+// send delivers the message with the first credential of the chain that succeeds.
+// Webhooks are skipped if the message uses a feature they do not support, so that the error
+// of the credential actually tried is returned. The outputs are exported once the message is delivered,
+// failing to export them does not fall back to the next credential as that would post the message again.
+func send(conf config, msg slack.Message) (slack.Response, error) {
+	var lastErr, skipErr error
+	tried := 0
+	for _, cred := range conf.Credentials {
+		if feature := apiOnlyFeature(conf); feature != "" && cred.isWebhook() {
+			skipErr = configurationErrorf("%s requires the API Token", feature)
+			if len(conf.Credentials) > 1 {
+				log.Warnf("Skipping the %s: %s", cred.Name, skipErr)
+			}
+			continue
+		}
+		if tried > 0 {
+			log.Warnf("Retrying with the %s", cred.Name)
+		}
+		tried++
+
+		attempt, attemptMsg, err := prepare(conf, msg, cred)
+		if err == nil {
+			var response slack.Response
+			if response, err = deliver(attempt, attemptMsg); err == nil {
+				if err := exportOutputs(&attempt, response); err != nil {
+					return response, fmt.Errorf("failed to export outputs: %s", err)
+				}
+				return response, nil
+			}
+		}
+
+		if len(conf.Credentials) == 1 {
+			return slack.Response{}, err
+		}
+		log.Warnf("Failed to send the message with the %s: %s", cred.Name, err)
+		lastErr = err
+	}
+	if tried == 0 {
+		return slack.Response{}, skipErr
+	}
+	return slack.Response{}, fmt.Errorf("failed to send the message with any of the credentials, last error: %w", lastErr)
+}
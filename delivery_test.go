@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-tools/go-steputils/stepconf"
+)
+
+func Test_send_fallback(t *testing.T) {
+	newFakeSlack(t, map[string]string{
+		"chat.postMessage": `{"ok": false, "error": "token_revoked"}`,
+	})
+
+	var received int
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+		fmt.Fprint(w, "ok")
+	}))
+	defer webhook.Close()
+
+	conf := config{
+		Channel:     "#general",
+		APIToken:    "token",
+		Credentials: newCredentialChain("", "token", "", stepconf.Secret(webhook.URL)),
+		PostAt:      1700000000,
+	}
+	if _, err := send(conf, newMessage(conf)); err != nil {
+		t.Fatalf("send() error = %v", err)
+	}
+	if received != 1 {
+		t.Errorf("webhook received %d messages, want 1", received)
+	}
+
+	conf.EphemeralUser = "U1"
+	if _, err := send(conf, newMessage(conf)); err == nil {
+		t.Errorf("send() expected an error, ephemeral messages can not fall back to webhooks")
+	}
+}
+
+func Test_send_skippedWebhookKeepsError(t *testing.T) {
+	newFakeSlack(t, map[string]string{
+		"chat.postMessage": `{"ok": false, "error": "service_unavailable"}`,
+	})
+
+	var received int
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+		fmt.Fprint(w, "ok")
+	}))
+	defer webhook.Close()
+
+	conf := config{
+		Channel:                    "#general",
+		APIToken:                   "token",
+		Credentials:                newCredentialChain("", "token", "", stepconf.Secret(webhook.URL)),
+		ThreadTsOutputVariableName: "SLACK_THREAD_TS",
+	}
+	_, err := send(conf, newMessage(conf))
+	if err == nil {
+		t.Fatalf("send() expected an error, Slack is unavailable")
+	}
+	if got := classify(err); got != errorClassNetwork {
+		t.Errorf("classify() = %s, want %s: %v", got, errorClassNetwork, err)
+	}
+	if received != 0 {
+		t.Errorf("webhook received %d messages, want 0", received)
+	}
+
+	conf.Credentials = newCredentialChain("", "", stepconf.Secret(webhook.URL), "")
+	if _, err := send(conf, newMessage(conf)); classify(err) != errorClassConfiguration {
+		t.Errorf("send() error = %v, want a configuration error when no credential can be tried", err)
+	}
+}
+
+func Test_send_exportFailure(t *testing.T) {
+	newFakeSlack(t, map[string]string{
+		"chat.postMessage": `{"ok": true, "channel": "C1", "ts": "1700000000.000100"}`,
+	})
+	// envman is not available, exporting the outputs fails.
+	t.Setenv("PATH", t.TempDir())
+
+	var received int
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+		fmt.Fprint(w, "ok")
+	}))
+	defer webhook.Close()
+
+	conf := config{
+		Channel:     "#general",
+		APIToken:    "token",
+		Credentials: newCredentialChain("", "token", "", stepconf.Secret(webhook.URL)),
+	}
+	if _, err := send(conf, newMessage(conf)); err == nil {
+		t.Errorf("send() expected an error, the outputs can not be exported")
+	}
+	if received != 0 {
+		t.Errorf("webhook received %d messages, want 0", received)
+	}
+}
+
+func Test_deliver_autoJoin(t *testing.T) {
+	tests := []struct {
+		name        string
+		autoJoin    bool
+		responses   map[string]string
+		wantMethods []string
+		wantErr     string
+		wantClass   errorClass
+	}{
+		{
+			name:     "Joins the public channel and retries",
+			autoJoin: true,
+			responses: map[string]string{
+				"chat.postMessage#1": `{"ok": false, "error": "not_in_channel"}`,
+				"chat.postMessage":   `{"ok": true, "channel": "C024BE91L", "ts": "1700000000.000100"}`,
+				"conversations.info": `{"ok": true, "channel": {"is_private": false}}`,
+				"conversations.join": `{"ok": true}`,
+			},
+			wantMethods: []string{"chat.postMessage", "conversations.info", "conversations.join", "chat.postMessage"},
+		},
+		{
+			name:     "Private channel can not be joined",
+			autoJoin: true,
+			responses: map[string]string{
+				"chat.postMessage":   `{"ok": false, "error": "not_in_channel"}`,
+				"conversations.info": `{"ok": true, "channel": {"is_private": true}}`,
+			},
+			wantMethods: []string{"chat.postMessage", "conversations.info"},
+			wantErr:     "is private",
+			wantClass:   errorClassConfiguration,
+		},
+		{
+			name: "Auto join disabled",
+			responses: map[string]string{
+				"chat.postMessage": `{"ok": false, "error": "not_in_channel"}`,
+			},
+			wantMethods: []string{"chat.postMessage"},
+			wantErr:     "enable auto_join_channel",
+			wantClass:   errorClassRejected,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := newFakeSlack(t, tt.responses)
+
+			conf := config{APIToken: "token", Channel: "C024BE91L", AutoJoinChannel: tt.autoJoin}
+			_, err := deliver(conf, newMessage(conf))
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("deliver() error = %v", err)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("deliver() error = %v, want %q", err, tt.wantErr)
+				}
+				if got := classify(err); got != tt.wantClass {
+					t.Errorf("classify() = %s, want %s", got, tt.wantClass)
+				}
+			}
+
+			var methods []string
+			for _, req := range *requests {
+				methods = append(methods, req.Method)
+			}
+			if !reflect.DeepEqual(methods, tt.wantMethods) {
+				t.Errorf("called methods = %v, want %v", methods, tt.wantMethods)
+			}
+		})
+	}
+}
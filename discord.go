@@ -0,0 +1,239 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+)
+
+// Size limits of Discord messages.
+// See also: https://discord.com/developers/docs/resources/channel#embed-object-embed-limits
+const (
+	discordContentLimit     = 2000
+	discordTitleLimit       = 256
+	discordDescriptionLimit = 4096
+	discordFieldCountLimit  = 25
+	discordFieldNameLimit   = 256
+	discordFieldValueLimit  = 1024
+	discordFooterLimit      = 2048
+	discordAuthorLimit      = 256
+	discordEmbedLimit       = 6000
+	discordEmbedCountLimit  = 10
+)
+
+// discordMaxRetries is the number of times a rate limited request is retried.
+const discordMaxRetries = 3
+
+// discordMaxRetryAfter is the longest wait for a rate limit to reset, longer ones fail the request.
+var discordMaxRetryAfter = 30 * time.Second
+
+// slackColors are the colours of Slack's named attachment colours.
+var slackColors = map[string]string{
+	"good":    "#2eb886",
+	"warning": "#daa038",
+	"danger":  "#a30200",
+}
+
+// discordMessage is the payload of a Discord webhook.
+// See also: https://discord.com/developers/docs/resources/webhook#execute-webhook
+type discordMessage struct {
+	Content   string         `json:"content,omitempty"`
+	Username  string         `json:"username,omitempty"`
+	AvatarURL string         `json:"avatar_url,omitempty"`
+	Embeds    []discordEmbed `json:"embeds,omitempty"`
+}
+
+// discordEmbed is the rich content of a Discord message.
+type discordEmbed struct {
+	Title       string         `json:"title,omitempty"`
+	URL         string         `json:"url,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Color       int            `json:"color,omitempty"`
+	Author      *discordText   `json:"author,omitempty"`
+	Fields      []discordField `json:"fields,omitempty"`
+	Footer      *discordText   `json:"footer,omitempty"`
+	Timestamp   string         `json:"timestamp,omitempty"`
+	Image       *discordImage  `json:"image,omitempty"`
+	Thumbnail   *discordImage  `json:"thumbnail,omitempty"`
+}
+
+// discordText is the author or the footer of an embed.
+type discordText struct {
+	Name    string `json:"name,omitempty"`
+	Text    string `json:"text,omitempty"`
+	IconURL string `json:"icon_url,omitempty"`
+}
+
+// discordField is a field of an embed, inline fields are displayed side-by-side.
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+// discordImage is the image or the thumbnail of an embed.
+type discordImage struct {
+	URL string `json:"url"`
+}
+
+// discordNotifier delivers the message to a Discord webhook as embeds.
+type discordNotifier struct {
+	webhookURL string
+
+	// threadID is the ID of the forum thread to post into.
+	threadID string
+}
+
+// Notify implements Notifier.
+func (n discordNotifier) Notify(msg slack.Message) (slack.Response, error) {
+	webhookURL := n.webhookURL
+	if n.threadID != "" {
+		u, err := url.Parse(webhookURL)
+		if err != nil {
+			return slack.Response{}, configurationErrorf("invalid Discord webhook URL: %s", err)
+		}
+		query := u.Query()
+		query.Set("thread_id", n.threadID)
+		u.RawQuery = query.Encode()
+		webhookURL = u.String()
+	}
+
+	payload := newDiscordMessage(msg)
+	for attempt := 0; ; attempt++ {
+		header, _, err := postJSON(webhookURL, payload)
+		var classified *classifiedError
+		if err == nil || !errors.As(err, &classified) || classified.Class != errorClassRateLimited || attempt == discordMaxRetries {
+			return slack.Response{OK: err == nil}, err
+		}
+
+		wait := retryAfter(header)
+		if wait > discordMaxRetryAfter {
+			return slack.Response{}, fmt.Errorf("%w, retry after %s", err, wait)
+		}
+		log.Warnf("Rate limited by Discord, retrying in %s", wait)
+		time.Sleep(wait)
+	}
+}
+
+// retryAfter returns the wait before retrying a rate limited request.
+func retryAfter(header http.Header) time.Duration {
+	for _, key := range []string{"Retry-After", "X-RateLimit-Reset-After"} {
+		if seconds, err := strconv.ParseFloat(header.Get(key), 64); err == nil {
+			return time.Duration(seconds * float64(time.Second))
+		}
+	}
+	return time.Second
+}
+
+// truncate shortens s to at most limit characters.
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	if limit <= 0 {
+		return ""
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+// discordColor converts a Slack attachment colour to the integer colour of an embed.
+func discordColor(color string) int {
+	if named, ok := slackColors[color]; ok {
+		color = named
+	}
+	rgb, err := strconv.ParseUint(strings.TrimPrefix(color, "#"), 16, 32)
+	if err != nil {
+		return 0
+	}
+	return int(rgb)
+}
+
+// newDiscordMessage converts the message to Discord's format and size limits.
+func newDiscordMessage(msg slack.Message) discordMessage {
+	var content []string
+	if msg.Text != "" {
+		content = append(content, toMarkdown(msg.Text))
+	}
+
+	dm := discordMessage{Username: msg.Username, AvatarURL: msg.IconURL}
+	for _, a := range msg.Attachments {
+		if a.PreText != "" {
+			content = append(content, toMarkdown(a.PreText))
+		}
+
+		description := toMarkdown(a.Text)
+		var links []string
+		for _, b := range a.Buttons {
+			links = append(links, fmt.Sprintf("[%s](%s)", b.Text, b.URL))
+		}
+		if len(links) > 0 {
+			description = strings.TrimSpace(description + "\n\n" + strings.Join(links, " · "))
+		}
+
+		embed := discordEmbed{
+			Title:       truncate(a.Title, discordTitleLimit),
+			URL:         a.TitleLink,
+			Description: truncate(description, discordDescriptionLimit),
+			Color:       discordColor(a.Color),
+		}
+		if a.AuthorName != "" {
+			embed.Author = &discordText{Name: truncate(a.AuthorName, discordAuthorLimit)}
+		}
+		for i, f := range a.Fields {
+			if i == discordFieldCountLimit {
+				break
+			}
+			embed.Fields = append(embed.Fields, discordField{
+				Name:   truncate(f.Title, discordFieldNameLimit),
+				Value:  truncate(toMarkdown(f.Value), discordFieldValueLimit),
+				Inline: len(f.Value) < 40,
+			})
+		}
+		if a.Footer != "" {
+			embed.Footer = &discordText{Text: truncate(a.Footer, discordFooterLimit), IconURL: a.FooterIcon}
+		}
+		if a.TimeStamp != 0 {
+			embed.Timestamp = time.Unix(int64(a.TimeStamp), 0).UTC().Format(time.RFC3339)
+		}
+		if a.ImageURL != "" {
+			embed.Image = &discordImage{URL: a.ImageURL}
+		}
+		if a.ThumbURL != "" {
+			embed.Thumbnail = &discordImage{URL: a.ThumbURL}
+		}
+
+		if excess := embedSize(embed) - discordEmbedLimit; excess > 0 {
+			embed.Description = truncate(embed.Description, len([]rune(embed.Description))-excess)
+		}
+		empty := embedSize(embed) == 0 && embed.Image == nil && embed.Thumbnail == nil
+		if !empty && len(dm.Embeds) < discordEmbedCountLimit {
+			dm.Embeds = append(dm.Embeds, embed)
+		}
+	}
+
+	dm.Content = truncate(strings.Join(content, "\n"), discordContentLimit)
+	return dm
+}
+
+// embedSize returns the number of characters of an embed counted towards its limit.
+func embedSize(e discordEmbed) int {
+	size := len([]rune(e.Title)) + len([]rune(e.Description))
+	if e.Author != nil {
+		size += len([]rune(e.Author.Name))
+	}
+	if e.Footer != nil {
+		size += len([]rune(e.Footer.Text))
+	}
+	for _, f := range e.Fields {
+		size += len([]rune(f.Name)) + len([]rune(f.Value))
+	}
+	return size
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+)
+
+func Test_newDiscordMessage(t *testing.T) {
+	msg := slack.Message{
+		Text:     "Build *#42* finished",
+		Username: "Bitrise",
+		Attachments: []slack.Attachment{{
+			Color:      "danger",
+			Title:      "Failed",
+			TitleLink:  "https://app.bitrise.io/build/1",
+			Text:       strings.Repeat("a", 5000),
+			Fields:     []slack.Field{{Title: "App", Value: "Demo"}, {Title: "Commit", Value: strings.Repeat("b", 40)}},
+			Footer:     "Bitrise",
+			FooterIcon: "https://bitrise.io/icon.png",
+			TimeStamp:  1405894322,
+			ThumbURL:   "https://bitrise.io/thumb.png",
+			Buttons:    []slack.Button{{Text: "Open", URL: "https://app.bitrise.io"}},
+		}},
+	}
+
+	got := newDiscordMessage(msg)
+	if got.Content != "Build **#42** finished" || got.Username != "Bitrise" {
+		t.Errorf("newDiscordMessage() content = %q, username = %q", got.Content, got.Username)
+	}
+	if len(got.Embeds) != 1 {
+		t.Fatalf("newDiscordMessage() embeds = %v, want 1", len(got.Embeds))
+	}
+
+	embed := got.Embeds[0]
+	if embed.Color != 0xa30200 {
+		t.Errorf("newDiscordMessage() color = %x, want a30200", embed.Color)
+	}
+	if n := len([]rune(embed.Description)); n != discordDescriptionLimit {
+		t.Errorf("newDiscordMessage() description length = %d, want %d", n, discordDescriptionLimit)
+	}
+	wantFields := []discordField{
+		{Name: "App", Value: "Demo", Inline: true},
+		{Name: "Commit", Value: strings.Repeat("b", 40), Inline: false},
+	}
+	if !reflect.DeepEqual(embed.Fields, wantFields) {
+		t.Errorf("newDiscordMessage() fields = %v, want %v", embed.Fields, wantFields)
+	}
+	if embed.Timestamp != "2014-07-20T22:12:02Z" {
+		t.Errorf("newDiscordMessage() timestamp = %v", embed.Timestamp)
+	}
+	if embed.Footer == nil || embed.Footer.IconURL != "https://bitrise.io/icon.png" || embed.Thumbnail == nil {
+		t.Errorf("newDiscordMessage() footer = %v, thumbnail = %v", embed.Footer, embed.Thumbnail)
+	}
+}
+
+func Test_discordNotifier_Notify(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RawQuery)
+		if len(requests) == 1 {
+			w.Header().Set("Retry-After", "0.01")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	n := discordNotifier{webhookURL: server.URL + "/api/webhooks/1/token", threadID: "1234567890"}
+	got, err := n.Notify(slack.Message{Text: "hello"})
+	if err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if !got.OK {
+		t.Errorf("Notify() = %v, want OK", got)
+	}
+	if want := []string{"thread_id=1234567890", "thread_id=1234567890"}; !reflect.DeepEqual(requests, want) {
+		t.Errorf("Notify() requests = %v, want %v", requests, want)
+	}
+}
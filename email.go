@@ -0,0 +1,381 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"html"
+	"html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+	"github.com/bitrise-tools/go-steputils/stepconf"
+)
+
+// SMTP connection security modes.
+const (
+	smtpSecurityStartTLS = "starttls"
+	smtpSecurityTLS      = "tls"
+	smtpSecurityNone     = "none"
+)
+
+// emailConfig configures the SMTP backend.
+type emailConfig struct {
+	Host     string
+	Port     string
+	Security string
+	Username string
+	Password stepconf.Secret
+	From     string
+
+	// Recipients are the addresses the email is sent to.
+	Recipients []string
+}
+
+// emailNotifier delivers the message as a multipart text and HTML email.
+type emailNotifier struct {
+	email   emailConfig
+	success bool
+
+	// tls configures the TLS connection to the server.
+	tls *tls.Config
+
+	// connectTimeout limits connecting to the server.
+	connectTimeout time.Duration
+
+	// timeout limits the whole SMTP conversation, so a stalling server does not hang the step.
+	timeout time.Duration
+}
+
+// emailSection is an attachment of the message rendered into the email.
+type emailSection struct {
+	PreText   template.HTML
+	Title     string
+	TitleLink string
+	Text      template.HTML
+	Fields    []emailField
+	ImageURL  string
+	Buttons   []slack.Button
+	Footer    template.HTML
+}
+
+// emailField is a row of the fields table.
+type emailField struct {
+	Title string
+	Value template.HTML
+}
+
+// emailTemplate renders the HTML part of the email.
+var emailTemplate = template.Must(template.New("email").Parse(`<!DOCTYPE html>
+<html>
+<body style="margin:0;padding:0;font-family:Helvetica,Arial,sans-serif;font-size:14px;color:#2b2b2b">
+<div style="background:{{ .Color }};color:#ffffff;padding:12px 16px;font-size:16px;font-weight:bold">{{ .Status }}</div>
+<div style="padding:16px">
+{{- if .Text }}
+<p>{{ .Text }}</p>
+{{- end }}
+{{- range .Sections }}
+{{- if .PreText }}
+<p style="color:#616061">{{ .PreText }}</p>
+{{- end }}
+{{- if .Title }}
+<h2 style="font-size:18px;margin:16px 0 8px">{{ if .TitleLink }}<a href="{{ .TitleLink }}">{{ .Title }}</a>{{ else }}{{ .Title }}{{ end }}</h2>
+{{- end }}
+{{- if .Text }}
+<p>{{ .Text }}</p>
+{{- end }}
+{{- if .Fields }}
+<table style="border-collapse:collapse;margin:8px 0">
+{{- range .Fields }}
+<tr><th style="text-align:left;padding:4px 12px 4px 0;vertical-align:top">{{ .Title }}</th><td style="padding:4px 0">{{ .Value }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+{{- if .ImageURL }}
+<p><img src="{{ .ImageURL }}" alt="" style="max-width:400px"></p>
+{{- end }}
+{{- if .Buttons }}
+<p>
+{{- range .Buttons }}
+<a href="{{ .URL }}" style="display:inline-block;margin:4px 8px 4px 0;padding:8px 14px;border:1px solid #c7c7c7;border-radius:4px;color:#2b2b2b;text-decoration:none">{{ .Text }}</a>
+{{- end }}
+</p>
+{{- end }}
+{{- if .Footer }}
+<p style="color:#868686;font-size:12px">{{ .Footer }}</p>
+{{- end }}
+{{- end }}
+</div>
+</body>
+</html>
+`))
+
+// mrkdwnToHTML escapes s and converts the links and bold text of Slack's mrkdwn format to HTML.
+func mrkdwnToHTML(s string) template.HTML {
+	var b strings.Builder
+	last := 0
+	for _, m := range slackLinkPattern.FindAllStringSubmatchIndex(s, -1) {
+		b.WriteString(slackBoldPattern.ReplaceAllString(html.EscapeString(s[last:m[0]]), "<b>$1</b>"))
+		url := s[m[2]:m[3]]
+		text := url
+		if m[4] >= 0 {
+			text = s[m[4]:m[5]]
+		}
+		fmt.Fprintf(&b, `<a href="%s">%s</a>`, html.EscapeString(url), html.EscapeString(text))
+		last = m[1]
+	}
+	b.WriteString(slackBoldPattern.ReplaceAllString(html.EscapeString(s[last:]), "<b>$1</b>"))
+	return template.HTML(strings.ReplaceAll(b.String(), "\n", "<br>\n"))
+}
+
+// mrkdwnToText converts the links of Slack's mrkdwn format to plain text.
+func mrkdwnToText(s string) string {
+	return slackLinkPattern.ReplaceAllStringFunc(s, func(link string) string {
+		m := slackLinkPattern.FindStringSubmatch(link)
+		if m[2] == "" {
+			return m[1]
+		}
+		return fmt.Sprintf("%s (%s)", m[2], m[1])
+	})
+}
+
+// emailSubject returns the subject of the email: the title of the message or the first line of its text.
+func emailSubject(msg slack.Message, success bool) string {
+	for _, a := range msg.Attachments {
+		if a.Title != "" {
+			return a.Title
+		}
+	}
+	if line := strings.TrimSpace(strings.SplitN(mrkdwnToText(msg.Text), "\n", 2)[0]); line != "" {
+		return line
+	}
+	if success {
+		return "Build succeeded"
+	}
+	return "Build failed"
+}
+
+// renderEmailText renders the plain text part of the email.
+func renderEmailText(msg slack.Message, status string) string {
+	lines := []string{status, ""}
+	if msg.Text != "" {
+		lines = append(lines, mrkdwnToText(msg.Text), "")
+	}
+	for _, a := range msg.Attachments {
+		for _, s := range []string{a.PreText, a.Title, a.TitleLink, a.Text} {
+			if s != "" {
+				lines = append(lines, mrkdwnToText(s))
+			}
+		}
+		for _, f := range a.Fields {
+			lines = append(lines, fmt.Sprintf("%s: %s", f.Title, mrkdwnToText(f.Value)))
+		}
+		for _, b := range a.Buttons {
+			lines = append(lines, fmt.Sprintf("%s: %s", b.Text, b.URL))
+		}
+		if a.Footer != "" {
+			lines = append(lines, "", mrkdwnToText(a.Footer))
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// renderEmailHTML renders the HTML part of the email with a status banner coloured as the attachment.
+func renderEmailHTML(msg slack.Message, status string) (string, error) {
+	data := struct {
+		Color    string
+		Status   string
+		Text     template.HTML
+		Sections []emailSection
+	}{Color: "#3bc3a3", Status: status, Text: mrkdwnToHTML(msg.Text)}
+
+	for _, a := range msg.Attachments {
+		if a.Color != "" {
+			data.Color = a.Color
+			if named, ok := slackColors[a.Color]; ok {
+				data.Color = named
+			}
+		}
+
+		section := emailSection{
+			PreText:   mrkdwnToHTML(a.PreText),
+			Title:     a.Title,
+			TitleLink: a.TitleLink,
+			Text:      mrkdwnToHTML(a.Text),
+			ImageURL:  a.ImageURL,
+			Buttons:   a.Buttons,
+			Footer:    mrkdwnToHTML(a.Footer),
+		}
+		for _, f := range a.Fields {
+			section.Fields = append(section.Fields, emailField{Title: f.Title, Value: mrkdwnToHTML(f.Value)})
+		}
+		data.Sections = append(data.Sections, section)
+	}
+
+	var buf bytes.Buffer
+	if err := emailTemplate.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// writeQuotedPrintablePart writes a quoted-printable encoded part of the multipart body.
+func writeQuotedPrintablePart(w *multipart.Writer, contentType, content string) error {
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType + "; charset=UTF-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	qp := quotedprintable.NewWriter(part)
+	if _, err := qp.Write([]byte(content)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// newEmail renders the message into a multipart/alternative email with a plain text and an HTML part.
+func newEmail(email emailConfig, msg slack.Message, success bool, now time.Time) ([]byte, error) {
+	status := "✅ Build succeeded"
+	if !success {
+		status = "❌ Build failed"
+	}
+
+	htmlBody, err := renderEmailHTML(msg, status)
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	if err := writeQuotedPrintablePart(w, "text/plain", renderEmailText(msg, status)); err != nil {
+		return nil, err
+	}
+	if err := writeQuotedPrintablePart(w, "text/html", htmlBody); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", email.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(email.Recipients, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", emailSubject(msg, success)))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", w.Boundary())
+	b.Write(body.Bytes())
+	return b.Bytes(), nil
+}
+
+// Notify implements Notifier.
+func (n emailNotifier) Notify(msg slack.Message) (slack.Response, error) {
+	email, err := newEmail(n.email, msg, n.success, time.Now())
+	if err != nil {
+		return slack.Response{}, err
+	}
+	if err := n.send(email); err != nil {
+		return slack.Response{}, err
+	}
+	return slack.Response{OK: true}, nil
+}
+
+// envelopeAddress returns the bare address of an address with an optional display name (eg. Bitrise <builds@example.com>).
+func envelopeAddress(address string) string {
+	if parsed, err := mail.ParseAddress(address); err == nil {
+		return parsed.Address
+	}
+	return address
+}
+
+// smtpError classifies an error of the SMTP conversation by its reply code.
+func smtpError(step string, err error) error {
+	class := errorClassNetwork
+	if tpErr, ok := err.(*textproto.Error); ok {
+		switch {
+		case tpErr.Code == 530 || tpErr.Code == 534 || tpErr.Code == 535:
+			class = errorClassAuthentication
+		case tpErr.Code >= 500:
+			class = errorClassRejected
+		}
+	}
+	return &classifiedError{Class: class, Err: fmt.Errorf("%s failed: %s", step, err)}
+}
+
+// send delivers the email to the recipients through the SMTP server.
+func (n emailNotifier) send(email []byte) error {
+	addr := net.JoinHostPort(n.email.Host, n.email.Port)
+	tlsConfig := n.tls.Clone()
+	tlsConfig.ServerName = n.email.Host
+
+	dialer := &net.Dialer{Timeout: n.connectTimeout}
+	var conn net.Conn
+	var err error
+	if n.email.Security == smtpSecurityTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return &classifiedError{Class: errorClassNetwork, Err: fmt.Errorf("failed to connect to %s: %s", addr, err)}
+	}
+	if n.timeout > 0 {
+		if err := conn.SetDeadline(time.Now().Add(n.timeout)); err != nil {
+			conn.Close()
+			return &classifiedError{Class: errorClassNetwork, Err: fmt.Errorf("failed to set the deadline of the connection: %s", err)}
+		}
+	}
+
+	client, err := smtp.NewClient(conn, n.email.Host)
+	if err != nil {
+		conn.Close()
+		return smtpError("greeting", err)
+	}
+	defer client.Close()
+
+	if n.email.Security == smtpSecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return configurationErrorf("the SMTP server (%s) does not support STARTTLS", addr)
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return smtpError("STARTTLS", err)
+		}
+	}
+
+	if n.email.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.email.Username, string(n.email.Password), n.email.Host)); err != nil {
+			return smtpError("authentication", err)
+		}
+	}
+
+	if err := client.Mail(envelopeAddress(n.email.From)); err != nil {
+		return smtpError("MAIL FROM", err)
+	}
+	for _, recipient := range n.email.Recipients {
+		if err := client.Rcpt(envelopeAddress(recipient)); err != nil {
+			return smtpError(fmt.Sprintf("RCPT TO %s", recipient), err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return smtpError("DATA", err)
+	}
+	if _, err := w.Write(email); err != nil {
+		return smtpError("DATA", err)
+	}
+	if err := w.Close(); err != nil {
+		return smtpError("DATA", err)
+	}
+	return client.Quit()
+}
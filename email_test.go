@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"html/template"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+)
+
+// fakeMail is the envelope and the data received by the fake SMTP server.
+type fakeMail struct {
+	Auth       string
+	From       string
+	Recipients []string
+	Data       string
+}
+
+// newFakeSMTP starts an SMTP stand-in accepting every message, returning its port and the received mail.
+func newFakeSMTP(t *testing.T) (string, *fakeMail) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	mail := &fakeMail{}
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(s string) { fmt.Fprintf(conn, "%s\r\n", s) }
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch command {
+			case "EHLO":
+				reply("250-localhost")
+				reply("250 AUTH PLAIN")
+			case "AUTH":
+				mail.Auth = line
+				reply("235 Authentication successful")
+			case "MAIL":
+				mail.From = strings.TrimPrefix(line, "MAIL FROM:")
+				reply("250 OK")
+			case "RCPT":
+				mail.Recipients = append(mail.Recipients, strings.TrimPrefix(line, "RCPT TO:"))
+				reply("250 OK")
+			case "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				mail.Data = data.String()
+				reply("250 OK")
+			case "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	return port, mail
+}
+
+func Test_emailNotifier_Notify(t *testing.T) {
+	port, mail := newFakeSMTP(t)
+
+	n := emailNotifier{
+		email: emailConfig{
+			Host:       "127.0.0.1",
+			Port:       port,
+			Security:   smtpSecurityNone,
+			Username:   "bitrise",
+			Password:   "secret",
+			From:       "Bitrise <builds@example.com>",
+			Recipients: []string{"qa@example.com", "client@example.com"},
+		},
+		tls:            &tls.Config{},
+		connectTimeout: time.Second,
+		timeout:        time.Second,
+	}
+	msg := slack.Message{
+		Text: "Build *#42* finished",
+		Attachments: []slack.Attachment{{
+			Color:  "danger",
+			Title:  "Failed",
+			Fields: []slack.Field{{Title: "App", Value: "Demo"}},
+		}},
+	}
+
+	if _, err := n.Notify(msg); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	if mail.Auth == "" || mail.From != "<builds@example.com>" {
+		t.Errorf("Notify() auth = %q, from = %q", mail.Auth, mail.From)
+	}
+	if want := []string{"<qa@example.com>", "<client@example.com>"}; !reflect.DeepEqual(mail.Recipients, want) {
+		t.Errorf("Notify() recipients = %v, want %v", mail.Recipients, want)
+	}
+	for _, want := range []string{"Subject: Failed", "To: qa@example.com, client@example.com", "multipart/alternative", "text/plain", "text/html", "App: Demo"} {
+		if !strings.Contains(mail.Data, want) {
+			t.Errorf("Notify() data does not contain %q:\n%s", want, mail.Data)
+		}
+	}
+}
+
+func Test_emailNotifier_stallingServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		// Accept the connection but never send the greeting.
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(5 * time.Second)
+		}
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	n := emailNotifier{
+		email:          emailConfig{Host: "127.0.0.1", Port: port, Security: smtpSecurityNone, From: "builds@example.com", Recipients: []string{"qa@example.com"}},
+		tls:            &tls.Config{},
+		connectTimeout: time.Second,
+		timeout:        100 * time.Millisecond,
+	}
+
+	start := time.Now()
+	if _, err := n.Notify(slack.Message{Text: "Build finished"}); err == nil {
+		t.Errorf("Notify() expected an error, the server never greets")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Notify() returned after %s, want the timeout to abort the conversation", elapsed)
+	}
+}
+
+func Test_mrkdwnToHTML(t *testing.T) {
+	got := mrkdwnToHTML("*Build* <https://app.bitrise.io/build/1?a=1&b=2|#42> <script>\nfailed")
+	want := template.HTML(`<b>Build</b> <a href="https://app.bitrise.io/build/1?a=1&amp;b=2">#42</a> &lt;script&gt;<br>` + "\n" + `failed`)
+	if got != want {
+		t.Errorf("mrkdwnToHTML() = %v, want %v", got, want)
+	}
+}
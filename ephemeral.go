@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+)
+
+// postEphemeral sends the message with chat.postEphemeral, so that it is only visible
+// to the ephemeral user in the channel. If the user is not a member of the channel,
+// the message is sent as a direct message instead.
+func postEphemeral(conf config, msg slack.Message) (slack.Response, error) {
+	user, err := resolveUser(conf.APIToken, conf.EphemeralUser)
+	if err != nil {
+		return slack.Response{}, fmt.Errorf("failed to resolve user %s: %w", conf.EphemeralUser, err)
+	}
+	msg.User = user
+
+	response, err := postMessage(conf, msg)
+	var apiErr *slack.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "user_not_in_channel" {
+		return response, err
+	}
+
+	log.Warnf("User %s is not a member of %s, sending a direct message instead", conf.EphemeralUser, msg.Channel)
+	channel, err := openDM(conf.APIToken, user)
+	if err != nil {
+		return response, fmt.Errorf("failed to open a direct message with %s: %w", conf.EphemeralUser, err)
+	}
+	msg.Channel = channel
+	msg.User = ""
+	return postMessage(conf, msg)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+)
+
+func Test_postEphemeral(t *testing.T) {
+	cache := userIDsByEmail
+	t.Cleanup(func() { userIDsByEmail = cache })
+	userIDsByEmail = map[string]string{}
+
+	tests := []struct {
+		name        string
+		user        string
+		responses   map[string]string
+		wantMethods []string
+		wantChannel string
+		wantUser    string
+	}{
+		{
+			name: "Ephemeral message to a user ID",
+			user: "U024BE7LH",
+			responses: map[string]string{
+				"chat.postEphemeral": `{"ok": true}`,
+			},
+			wantMethods: []string{"chat.postEphemeral"},
+			wantChannel: "C024BE91L",
+			wantUser:    "U024BE7LH",
+		},
+		{
+			name: "Email address is looked up",
+			user: "dev@example.com",
+			responses: map[string]string{
+				"users.lookupByEmail": `{"ok": true, "user": {"id": "U0000DEV1"}}`,
+				"chat.postEphemeral":  `{"ok": true}`,
+			},
+			wantMethods: []string{"users.lookupByEmail", "chat.postEphemeral"},
+			wantChannel: "C024BE91L",
+			wantUser:    "U0000DEV1",
+		},
+		{
+			name: "User not in channel falls back to a direct message",
+			user: "U024BE7LH",
+			responses: map[string]string{
+				"chat.postEphemeral": `{"ok": false, "error": "user_not_in_channel"}`,
+				"conversations.open": `{"ok": true, "channel": {"id": "D0000DM01"}}`,
+				"chat.postMessage":   `{"ok": true, "channel": "D0000DM01", "ts": "1700000000.000100"}`,
+			},
+			wantMethods: []string{"chat.postEphemeral", "conversations.open", "chat.postMessage"},
+			wantChannel: "D0000DM01",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := newFakeSlack(t, tt.responses)
+
+			conf := config{APIToken: "token", Channel: "C024BE91L", EphemeralUser: tt.user}
+			if _, err := postEphemeral(conf, newMessage(conf)); err != nil {
+				t.Fatalf("postEphemeral() error = %v", err)
+			}
+
+			var methods []string
+			for _, req := range *requests {
+				methods = append(methods, req.Method)
+			}
+			if !reflect.DeepEqual(methods, tt.wantMethods) {
+				t.Fatalf("called methods = %v, want %v", methods, tt.wantMethods)
+			}
+
+			if tt.wantMethods[0] == "users.lookupByEmail" {
+				if params, _ := url.ParseQuery((*requests)[0].Body); params.Get("email") != tt.user {
+					t.Errorf("users.lookupByEmail email = %s, want %s", params.Get("email"), tt.user)
+				}
+			}
+			for _, req := range *requests {
+				if req.Method == "conversations.open" {
+					if params, _ := url.ParseQuery(req.Body); params.Get("users") != tt.user {
+						t.Errorf("conversations.open users = %s, want %s", params.Get("users"), tt.user)
+					}
+				}
+			}
+
+			var posted slack.Message
+			if err := json.Unmarshal([]byte((*requests)[len(*requests)-1].Body), &posted); err != nil {
+				t.Fatalf("failed to parse the posted message: %s", err)
+			}
+			if posted.Channel != tt.wantChannel || posted.User != tt.wantUser {
+				t.Errorf("posted channel = %s, user = %s, want %s, %s", posted.Channel, posted.User, tt.wantChannel, tt.wantUser)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+)
+
+// errorClassOutputKey is the output exporting the class of the error the step failed with.
+const errorClassOutputKey = "SLACK_ERROR_CLASS"
+
+// errorClass categorizes the errors of the step, so that users can decide which ones should fail the build.
+type errorClass string
+
+const (
+	errorClassConfiguration  errorClass = "configuration"
+	errorClassAuthentication errorClass = "authentication"
+	errorClassRateLimited    errorClass = "rate_limited"
+	errorClassNetwork        errorClass = "network"
+	errorClassRejected       errorClass = "rejected"
+)
+
+// errorClasses lists the valid error classes.
+var errorClasses = []errorClass{
+	errorClassConfiguration,
+	errorClassAuthentication,
+	errorClassRateLimited,
+	errorClassNetwork,
+	errorClassRejected,
+}
+
+// apiErrorClasses maps the Slack Web API error codes to error classes, the rest of them are rejections.
+var apiErrorClasses = map[string]errorClass{
+	"not_authed":             errorClassAuthentication,
+	"invalid_auth":           errorClassAuthentication,
+	"account_inactive":       errorClassAuthentication,
+	"token_revoked":          errorClassAuthentication,
+	"token_expired":          errorClassAuthentication,
+	"no_permission":          errorClassAuthentication,
+	"missing_scope":          errorClassAuthentication,
+	"not_allowed_token_type": errorClassAuthentication,
+	"ekm_access_denied":      errorClassAuthentication,
+	"ratelimited":            errorClassRateLimited,
+	"rate_limited":           errorClassRateLimited,
+	"service_unavailable":    errorClassNetwork,
+	"request_timeout":        errorClassNetwork,
+	"internal_error":         errorClassNetwork,
+	"fatal_error":            errorClassNetwork,
+}
+
+// classifiedError is an error with a class.
+type classifiedError struct {
+	Class errorClass
+	Err   error
+}
+
+// Error implements builtin errors.Error.
+func (e *classifiedError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *classifiedError) Unwrap() error {
+	return e.Err
+}
+
+// configurationErrorf returns a configuration error formatted according to a format specifier.
+func configurationErrorf(format string, v ...interface{}) error {
+	return &classifiedError{Class: errorClassConfiguration, Err: fmt.Errorf(format, v...)}
+}
+
+// statusClass classifies an unexpected HTTP status code,
+// client errors other than authentication and rate limiting are classified as fallback.
+func statusClass(statusCode int, fallback errorClass) errorClass {
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return errorClassAuthentication
+	case statusCode == http.StatusTooManyRequests:
+		return errorClassRateLimited
+	case statusCode >= http.StatusInternalServerError:
+		return errorClassNetwork
+	}
+	return fallback
+}
+
+// statusError wraps an error caused by an unexpected HTTP status code.
+func statusError(statusCode int, fallback errorClass, err error) error {
+	return &classifiedError{Class: statusClass(statusCode, fallback), Err: err}
+}
+
+// classify returns the class of the error, unclassified errors are configuration errors.
+func classify(err error) errorClass {
+	var classified *classifiedError
+	if errors.As(err, &classified) {
+		return classified.Class
+	}
+	var apiErr *slack.APIError
+	if errors.As(err, &apiErr) {
+		if class, ok := apiErrorClasses[apiErr.Code]; ok {
+			return class
+		}
+		return errorClassRejected
+	}
+	var statusErr *slack.StatusError
+	if errors.As(err, &statusErr) {
+		return statusClass(statusErr.StatusCode, errorClassRejected)
+	}
+	var requestErr *slack.RequestError
+	if errors.As(err, &requestErr) {
+		return errorClassNetwork
+	}
+	return errorClassConfiguration
+}
+
+// parseErrorClasses parses a comma or newline separated list of error classes, all meaning every class.
+func parseErrorClasses(s string) ([]errorClass, error) {
+	var classes []errorClass
+	for _, item := range parseList(s) {
+		item = strings.ToLower(item)
+		if item == "all" {
+			return errorClasses, nil
+		}
+
+		valid := false
+		for _, class := range errorClasses {
+			if string(class) == item {
+				classes = append(classes, class)
+				valid = true
+			}
+		}
+		if !valid {
+			return nil, configurationErrorf("invalid error class: %s", item)
+		}
+	}
+	return classes, nil
+}
+
+// exitWithError logs the error, exports its class and exits with status 1,
+// or with 0 if the class of the error is listed as non-fatal.
+func exitWithError(nonFatal []errorClass, err error) {
+	class := classify(err)
+	if exportErr := exportEnvVariable(errorClassOutputKey, string(class)); exportErr != nil {
+		log.Warnf("Failed to export outputs: %s", exportErr)
+	}
+
+	for _, c := range nonFatal {
+		if c == class {
+			log.Warnf("Error (%s): %s\n", class, err)
+			log.Warnf("The step does not fail, %s errors are configured to be non-fatal.", class)
+			os.Exit(0)
+		}
+	}
+
+	log.Errorf("Error (%s): %s\n", class, err)
+	os.Exit(1)
+}
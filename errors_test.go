@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+)
+
+func Test_classify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want errorClass
+	}{
+		{
+			name: "Unclassified error",
+			err:  errors.New("invalid input"),
+			want: errorClassConfiguration,
+		},
+		{
+			name: "Authentication API error",
+			err:  fmt.Errorf("failed to send the message: %w", &slack.APIError{Method: "chat.postMessage", Code: "invalid_auth"}),
+			want: errorClassAuthentication,
+		},
+		{
+			name: "Rejected API error",
+			err:  &slack.APIError{Method: "chat.postMessage", Code: "channel_not_found"},
+			want: errorClassRejected,
+		},
+		{
+			name: "Rate limited status",
+			err:  &slack.StatusError{StatusCode: http.StatusTooManyRequests, Status: "429 Too Many Requests"},
+			want: errorClassRateLimited,
+		},
+		{
+			name: "Network error",
+			err:  fmt.Errorf("last error: %w", &slack.RequestError{Err: errors.New("connection refused")}),
+			want: errorClassNetwork,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classify(tt.err); got != tt.want {
+				t.Errorf("classify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseErrorClasses(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    []errorClass
+		wantErr bool
+	}{
+		{name: "Empty", s: "", want: nil},
+		{name: "List", s: "network, Rate_Limited", want: []errorClass{errorClassNetwork, errorClassRateLimited}},
+		{name: "All", s: "all", want: errorClasses},
+		{name: "Invalid", s: "network,timeout", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseErrorClasses(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseErrorClasses() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseErrorClasses() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-utils/parseutil"
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+	"github.com/bitrise-tools/go-steputils/stepconf"
+)
+
+// buildEventType is the metadata event type of the messages sent by this step.
+const buildEventType = "bitrise_build_finished"
+
+// maxHistoryPages limits how many pages of the channel history are inspected
+// when counting consecutive failures.
+const maxHistoryPages = 5
+
+// escalationRule overrides the message settings once the number of
+// consecutive failed builds reaches Threshold.
+type escalationRule struct {
+	Threshold      int
+	Channel        string
+	Mention        string
+	ReplyBroadcast bool
+}
+
+// parseEscalationRules parses lines in the form of
+// threshold|channel|mention|reply_broadcast into rules sorted by threshold.
+// Empty lines are omitted, all columns but the threshold are optional.
+func parseEscalationRules(s string) ([]escalationRule, error) {
+	var rules []escalationRule
+	for _, line := range strings.Split(s, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		cols := strings.Split(line, "|")
+		for len(cols) < 4 {
+			cols = append(cols, "")
+		}
+
+		threshold, err := strconv.Atoi(strings.TrimSpace(cols[0]))
+		if err != nil || threshold < 1 {
+			return nil, fmt.Errorf("invalid escalation threshold in line: %s", line)
+		}
+		rule := escalationRule{
+			Threshold: threshold,
+			Channel:   strings.TrimSpace(cols[1]),
+			Mention:   strings.TrimSpace(cols[2]),
+		}
+		if broadcast := strings.TrimSpace(cols[3]); broadcast != "" {
+			if rule.ReplyBroadcast, err = parseutil.ParseBool(broadcast); err != nil {
+				return nil, fmt.Errorf("invalid escalation reply_broadcast value in line: %s", line)
+			}
+		}
+		rules = append(rules, rule)
+	}
+
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].Threshold < rules[j].Threshold })
+	return rules, nil
+}
+
+// buildMetadata returns the metadata identifying the build the message is sent for.
+func buildMetadata(inp *Input, success bool) *slack.Metadata {
+	status := "failed"
+	if success {
+		status = "succeeded"
+	}
+	return &slack.Metadata{
+		EventType: buildEventType,
+		EventPayload: map[string]string{
+			"app_slug":     inp.AppSlug,
+			"workflow":     inp.WorkflowID,
+			"branch":       inp.GitBranch,
+			"build_number": inp.BuildNumber,
+			"status":       status,
+		},
+	}
+}
+
+// sameBuildSeries tells whether two build metadata belong to the same app, workflow and branch.
+func sameBuildSeries(a, b *slack.Metadata) bool {
+	if a == nil || b == nil || a.EventType != b.EventType {
+		return false
+	}
+	for _, key := range []string{"app_slug", "workflow", "branch"} {
+		if a.EventPayload[key] != b.EventPayload[key] {
+			return false
+		}
+	}
+	return true
+}
+
+// seriesMessage is a message of the same build series found in a channel history.
+type seriesMessage struct {
+	Ts     float64
+	Failed bool
+}
+
+// seriesHistory walks the channel history backwards and returns the messages of the same
+// series reported by this step, stopping at the first successful build or once limit failures are found.
+func seriesHistory(token stepconf.Secret, channel string, current *slack.Metadata, limit int) ([]seriesMessage, error) {
+	var messages []seriesMessage
+	failures := 0
+	cursor := ""
+	for page := 0; page < maxHistoryPages; page++ {
+		var history struct {
+			slack.APIResponse
+			Messages []struct {
+				Ts       string          `json:"ts"`
+				Metadata *slack.Metadata `json:"metadata"`
+			} `json:"messages"`
+		}
+		params := url.Values{
+			"channel":              {channel},
+			"limit":                {"100"},
+			"include_all_metadata": {"true"},
+		}
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+		if err := callAPI(token, "conversations.history", params, &history); err != nil {
+			return nil, err
+		}
+
+		for _, m := range history.Messages {
+			if !sameBuildSeries(m.Metadata, current) {
+				continue
+			}
+			ts, _ := strconv.ParseFloat(m.Ts, 64)
+			failed := m.Metadata.EventPayload["status"] == "failed"
+			messages = append(messages, seriesMessage{Ts: ts, Failed: failed})
+			if !failed {
+				return messages, nil
+			}
+			if failures++; failures >= limit {
+				return messages, nil
+			}
+		}
+
+		cursor = history.ResponseMetadata.NextCursor
+		if cursor == "" {
+			break
+		}
+	}
+	return messages, nil
+}
+
+// countConsecutiveFailures counts the failed builds of the same series reported by this step
+// in the given channels, newest first, stopping at the first successful one or once limit is reached.
+// The escalated messages are sent to other channels, so all of them have to be inspected.
+func countConsecutiveFailures(token stepconf.Secret, channels []string, current *slack.Metadata, limit int) (int, error) {
+	var messages []seriesMessage
+	for _, channel := range channels {
+		history, err := seriesHistory(token, channel, current, limit)
+		if err != nil {
+			return 0, err
+		}
+		messages = append(messages, history...)
+	}
+	sort.SliceStable(messages, func(i, j int) bool { return messages[i].Ts > messages[j].Ts })
+
+	count := 0
+	for _, m := range messages {
+		if !m.Failed || count >= limit {
+			break
+		}
+		count++
+	}
+	return count, nil
+}
+
+// addMention prefixes the text with the given mention (eg. <!subteam^S0123>).
+func addMention(text, mention string) string {
+	if mention == "" {
+		return text
+	}
+	if text == "" {
+		return mention
+	}
+	return mention + " " + text
+}
+
+// historyChannels returns the channels the messages of the build series may have been sent to:
+// the target of the failure, the target of the successes, the channels the routing rules
+// send successes to and the channels the escalation rules escalate to.
+func historyChannels(c *config) []string {
+	channels := []string{c.Channel, c.SuccessChannel}
+	for _, rule := range c.RoutingRules {
+		if rule.Status != "failure" && rule.Channel != dropChannel {
+			channels = append(channels, rule.Channel)
+		}
+	}
+	for _, rule := range c.EscalationRules {
+		channels = append(channels, rule.Channel)
+	}
+	return channels
+}
+
+// escalate applies the highest escalation rule reached by the number of consecutive failures.
+func escalate(c *config) error {
+	if c.Success || len(c.EscalationRules) == 0 {
+		return nil
+	}
+
+	var channels []string
+	seen := map[string]bool{}
+	for _, name := range historyChannels(c) {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		channel, err := channelID(c.APIToken, name)
+		if err != nil {
+			return fmt.Errorf("failed to resolve channel %s: %s", name, err)
+		}
+		if !seen[channel] {
+			seen[channel] = true
+			channels = append(channels, channel)
+		}
+	}
+
+	highest := c.EscalationRules[len(c.EscalationRules)-1].Threshold
+	previous, err := countConsecutiveFailures(c.APIToken, channels, c.Metadata, highest)
+	if err != nil {
+		return fmt.Errorf("failed to count consecutive failures: %s", err)
+	}
+	failures := previous + 1
+	log.Infof("Consecutive failed builds: %d", failures)
+
+	var rule *escalationRule
+	for i := range c.EscalationRules {
+		if c.EscalationRules[i].Threshold <= failures {
+			rule = &c.EscalationRules[i]
+		}
+	}
+	if rule == nil {
+		return nil
+	}
+
+	log.Warnf("Escalating notification (threshold: %d)", rule.Threshold)
+	if rule.Channel != "" {
+		c.Channel = rule.Channel
+	}
+	c.Text = addMention(c.Text, rule.Mention)
+	c.ReplyBroadcast = c.ReplyBroadcast || rule.ReplyBroadcast
+	return nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+)
+
+func Test_parseEscalationRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    []escalationRule
+		wantErr bool
+	}{
+		{
+			name: "Rules are sorted by threshold",
+			s:    "5|#oncall|<!channel>|yes\n\n3||<!subteam^S01>",
+			want: []escalationRule{
+				{Threshold: 3, Mention: "<!subteam^S01>"},
+				{Threshold: 5, Channel: "#oncall", Mention: "<!channel>", ReplyBroadcast: true},
+			},
+		},
+		{
+			name:    "Invalid threshold",
+			s:       "three|#oncall",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEscalationRules(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseEscalationRules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseEscalationRules() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_countConsecutiveFailures(t *testing.T) {
+	message := func(branch, status string) string {
+		return fmt.Sprintf(`{"metadata": {"event_type": "%s", "event_payload": {"app_slug": "app", "workflow": "primary", "branch": "%s", "status": "%s"}}}`, buildEventType, branch, status)
+	}
+	newFakeSlack(t, map[string]string{
+		"conversations.history": fmt.Sprintf(`{"ok": true, "messages": [%s, {"text": "unrelated"}, %s, %s, %s]}`,
+			message("main", "failed"), message("feature", "succeeded"), message("main", "failed"), message("main", "succeeded")),
+	})
+
+	current := &slack.Metadata{EventType: buildEventType, EventPayload: map[string]string{"app_slug": "app", "workflow": "primary", "branch": "main"}}
+
+	got, err := countConsecutiveFailures("token", []string{"C024BE91L"}, current, 10)
+	if err != nil {
+		t.Fatalf("countConsecutiveFailures() error = %v", err)
+	}
+	if got != 2 {
+		t.Errorf("countConsecutiveFailures() = %d, want 2", got)
+	}
+}
+
+func Test_escalate_acrossChannels(t *testing.T) {
+	message := func(ts, status string) string {
+		return fmt.Sprintf(`{"ts": "%s", "metadata": {"event_type": "%s", "event_payload": {"app_slug": "app", "workflow": "primary", "branch": "main", "status": "%s"}}}`, ts, buildEventType, status)
+	}
+	history := func(messages ...string) string {
+		return fmt.Sprintf(`{"ok": true, "messages": [%s]}`, strings.Join(messages, ", "))
+	}
+
+	tests := []struct {
+		name           string
+		channel        string
+		successChannel string
+		rules          string
+		histories      map[string]string
+		wantChannel    string
+		wantText       string
+	}{
+		{
+			// The first two failures were posted to #builds, the escalated ones to #oncall.
+			name:           "Escalated failures are counted",
+			channel:        "#builds",
+			successChannel: "#builds",
+			rules:          "3|#oncall\n5|#oncall|<!channel>",
+			histories: map[string]string{
+				"C0000BUILDS": history(message("1700000002.000000", "failed"), message("1700000001.000000", "failed"), message("1700000000.000000", "succeeded")),
+				"C0000ONCALL": history(message("1700000004.000000", "failed"), message("1700000003.000000", "failed")),
+			},
+			wantChannel: "#oncall",
+			wantText:    "<!channel> Build failed",
+		},
+		{
+			// Failures are sent to #alerts (channel_on_error), successes to #builds.
+			name:           "Success in the success channel resets the streak",
+			channel:        "#alerts",
+			successChannel: "#builds",
+			rules:          "3||<!channel>",
+			histories: map[string]string{
+				"C0000ALERTS": history(message("1700000001.000000", "failed"), message("1700000000.000000", "failed")),
+				"C0000BUILDS": history(message("1700000002.000000", "succeeded")),
+			},
+			wantChannel: "#alerts",
+			wantText:    "Build failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path[1:] {
+				case "conversations.list":
+					fmt.Fprint(w, `{"ok": true, "channels": [{"id": "C0000BUILDS", "name": "builds"}, {"id": "C0000ONCALL", "name": "oncall"}, {"id": "C0000ALERTS", "name": "alerts"}]}`)
+				case "conversations.history":
+					if body, ok := tt.histories[r.FormValue("channel")]; ok {
+						fmt.Fprint(w, body)
+						return
+					}
+					fmt.Fprint(w, `{"ok": true, "messages": []}`)
+				default:
+					fmt.Fprint(w, `{"ok": false, "error": "unknown_method"}`)
+				}
+			}))
+			defer server.Close()
+
+			url, cache := slackAPIURL, channelIDsByName
+			t.Cleanup(func() { slackAPIURL, channelIDsByName = url, cache })
+			slackAPIURL, channelIDsByName = server.URL, map[string]string{}
+
+			rules, err := parseEscalationRules(tt.rules)
+			if err != nil {
+				t.Fatalf("parseEscalationRules() error = %v", err)
+			}
+			conf := config{
+				APIToken:        "token",
+				Channel:         tt.channel,
+				SuccessChannel:  tt.successChannel,
+				Text:            "Build failed",
+				EscalationRules: rules,
+				Metadata:        &slack.Metadata{EventType: buildEventType, EventPayload: map[string]string{"app_slug": "app", "workflow": "primary", "branch": "main"}},
+			}
+			if err := escalate(&conf); err != nil {
+				t.Fatalf("escalate() error = %v", err)
+			}
+			if conf.Channel != tt.wantChannel || conf.Text != tt.wantText {
+				t.Errorf("escalate() channel = %s, text = %s, want %s, %s", conf.Channel, conf.Text, tt.wantChannel, tt.wantText)
+			}
+		})
+	}
+}
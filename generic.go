@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-utils/retry"
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+// genericWebhookMethods are the HTTP methods the generic webhook can be called with.
+var genericWebhookMethods = []string{"POST", "PUT", "PATCH"}
+
+// sensitiveNamePattern matches the names of the headers and payload fields whose values are redacted from the logs.
+var sensitiveNamePattern = regexp.MustCompile(`(?i)authorization|cookie|token|key|secret|signature|password`)
+
+// genericWebhook configures the request of the generic webhook backend.
+type genericWebhook struct {
+	Method  string
+	Headers http.Header
+	Payload *template.Template
+}
+
+// templateData is the data the payload template of the generic webhook is rendered with.
+type templateData struct {
+	// Success tells whether the build succeeded, Status is its text form: success or failure.
+	Success bool
+	Status  string
+
+	// Message is the message built from the inputs, as it would be sent to Slack.
+	Message slack.Message
+
+	// Build describes the running build.
+	Build struct {
+		Number   string
+		URL      string
+		AppSlug  string
+		Workflow string
+		Branch   string
+	}
+}
+
+// templateFuncs are the functions available in the payload template.
+var templateFuncs = template.FuncMap{
+	// json encodes a value as JSON, eg. {{ json .Message.Text }} renders a quoted and escaped string.
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// parseHeaders parses the headers given one per line in Name: value format.
+func parseHeaders(s string) (http.Header, error) {
+	headers := http.Header{}
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		a := strings.SplitN(line, ":", 2)
+		if len(a) != 2 || strings.TrimSpace(a[0]) == "" {
+			return nil, configurationErrorf("invalid header (%s), use the Name: value format", line)
+		}
+		name, value := strings.TrimSpace(a[0]), strings.TrimSpace(a[1])
+		if sensitiveNamePattern.MatchString(name) {
+			registerSecret(value)
+		}
+		headers.Add(name, value)
+	}
+	return headers, nil
+}
+
+// parseGenericWebhook parses the method, headers and payload template of the generic webhook.
+func parseGenericWebhook(method, headers, payload string) (genericWebhook, error) {
+	var webhook genericWebhook
+
+	webhook.Method = strings.ToUpper(strings.TrimSpace(method))
+	if webhook.Method == "" {
+		webhook.Method = "POST"
+	}
+	valid := false
+	for _, m := range genericWebhookMethods {
+		valid = valid || m == webhook.Method
+	}
+	if !valid {
+		return webhook, configurationErrorf("invalid webhook method: %s, use one of %s", method, strings.Join(genericWebhookMethods, ", "))
+	}
+
+	var err error
+	if webhook.Headers, err = parseHeaders(headers); err != nil {
+		return webhook, err
+	}
+
+	if strings.TrimSpace(payload) == "" {
+		return webhook, configurationErrorf("The generic webhook backend requires a payload template, set the webhook_payload_template input.")
+	}
+	if webhook.Payload, err = template.New("payload").Funcs(templateFuncs).Option("missingkey=error").Parse(payload); err != nil {
+		return webhook, configurationErrorf("invalid payload template: %s", err)
+	}
+	return webhook, nil
+}
+
+// newTemplateData returns the data of the payload template.
+func newTemplateData(conf config, msg slack.Message) templateData {
+	data := templateData{Success: conf.Success, Status: "failure", Message: msg}
+	if conf.Success {
+		data.Status = "success"
+	}
+	data.Build.Number = conf.BuildNumber
+	data.Build.URL = conf.BuildURL
+	data.Build.AppSlug = conf.AppSlug
+	data.Build.Workflow = conf.WorkflowID
+	data.Build.Branch = conf.GitBranch
+	return data
+}
+
+// renderPayload renders the payload template and checks that the result is valid JSON.
+func renderPayload(tmpl *template.Template, data templateData) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, configurationErrorf("failed to render the payload template: %s", err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		// The payload is not logged, it may contain expanded credentials.
+		return nil, configurationErrorf("the rendered payload is not valid JSON, check the webhook payload template")
+	}
+	return buf.Bytes(), nil
+}
+
+// redactPayload returns the payload to log, with the values of the sensitive fields
+// (eg. routing_key or api_token) replaced and registered as secrets.
+func redactPayload(body []byte) string {
+	var payload interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return redacted
+	}
+	b, err := json.Marshal(redactFields(payload))
+	if err != nil {
+		return redacted
+	}
+	return string(b)
+}
+
+// redactFields walks the decoded JSON and redacts the values of the sensitive fields.
+func redactFields(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if !sensitiveNamePattern.MatchString(key) {
+				v[key] = redactFields(value)
+				continue
+			}
+			if s, ok := value.(string); ok {
+				registerSecret(s)
+			}
+			v[key] = redacted
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = redactFields(v[i])
+		}
+	}
+	return v
+}
+
+// genericWebhookNotifier calls an arbitrary endpoint with the rendered payload template,
+// retrying on network errors and server side errors.
+type genericWebhookNotifier struct {
+	url     string
+	webhook genericWebhook
+	conf    config
+}
+
+// Notify implements Notifier.
+func (n genericWebhookNotifier) Notify(msg slack.Message) (slack.Response, error) {
+	body, err := renderPayload(n.webhook.Payload, newTemplateData(n.conf, msg))
+	if err != nil {
+		return slack.Response{}, err
+	}
+	log.Debugf("Request to webhook: %s\n", redactPayload(body))
+
+	req, err := retryablehttp.NewRequest(n.webhook.Method, n.url, body)
+	if err != nil {
+		return slack.Response{}, configurationErrorf("invalid webhook request: %s", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	for name, values := range n.webhook.Headers {
+		req.Header[name] = values
+	}
+
+	client := retry.NewHTTPClient()
+	client.HTTPClient = httpClient
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return slack.Response{}, &classifiedError{Class: errorClassNetwork, Err: fmt.Errorf("failed to send the request: %s", err)}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return slack.Response{}, &classifiedError{Class: errorClassNetwork, Err: fmt.Errorf("server error: %s, failed to read response: %s", resp.Status, err)}
+	}
+	log.Debugf("Response from webhook: %s\n", respBody)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return slack.Response{}, statusError(resp.StatusCode, errorClassRejected, fmt.Errorf("server error: %s, response: %s", resp.Status, respBody))
+	}
+	return slack.Response{OK: true}, nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+)
+
+func Test_parseGenericWebhook(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		headers string
+		payload string
+		wantErr bool
+	}{
+		{name: "Defaults", payload: `{"status": "{{ .Status }}"}`},
+		{name: "Headers", method: "put", headers: "Authorization: Token token=abc\nX-Source: bitrise", payload: `{}`},
+		{name: "Invalid method", method: "DELETE", payload: `{}`, wantErr: true},
+		{name: "Invalid header", headers: "Authorization", payload: `{}`, wantErr: true},
+		{name: "Missing template", wantErr: true},
+		{name: "Invalid template", payload: `{"status": "{{ .Status }"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseGenericWebhook(tt.method, tt.headers, tt.payload); (err != nil) != tt.wantErr {
+				t.Errorf("parseGenericWebhook() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_redactPayload(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "Sensitive fields are redacted",
+			body: `{"routing_key": "R0123456789", "event_action": "trigger", "payload": {"summary": "Build failed", "api_token": "T0123"}}`,
+			want: `{"event_action":"trigger","payload":{"api_token":"[REDACTED]","summary":"Build failed"},"routing_key":"[REDACTED]"}`,
+		},
+		{
+			name: "Sensitive fields in arrays",
+			body: `[{"secret": {"value": "S0123"}}, {"name": "build"}]`,
+			want: `[{"secret":"[REDACTED]"},{"name":"build"}]`,
+		},
+		{
+			name: "Invalid JSON is not logged",
+			body: `{"routing_key": "R0123456789"`,
+			want: "[REDACTED]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactPayload([]byte(tt.body)); got != tt.want {
+				t.Errorf("redactPayload() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_genericWebhookNotifier_Notify(t *testing.T) {
+	var gotMethod, gotAuth, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		gotMethod, gotAuth, gotBody = r.Method, r.Header.Get("Authorization"), string(b)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	webhook, err := parseGenericWebhook("PUT", "Authorization: Token token=abc",
+		`{"summary": {{ json .Message.Text }}, "severity": "{{ if .Success }}info{{ else }}critical{{ end }}", "build": "{{ .Build.Number }}"}`)
+	if err != nil {
+		t.Fatalf("parseGenericWebhook() error = %v", err)
+	}
+
+	n := genericWebhookNotifier{url: server.URL, webhook: webhook, conf: config{BuildNumber: "42"}}
+	if _, err := n.Notify(slack.Message{Text: `Build "#42" failed`}); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	if gotMethod != "PUT" || gotAuth != "Token token=abc" {
+		t.Errorf("Notify() method = %v, authorization = %v", gotMethod, gotAuth)
+	}
+	if want := `{"summary": "Build \"#42\" failed", "severity": "critical", "build": "42"}`; gotBody != want {
+		t.Errorf("Notify() body = %v, want %v", gotBody, want)
+	}
+}
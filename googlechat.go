@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+)
+
+// googleChatMessage is the payload of a Google Chat incoming webhook.
+// See also: https://developers.google.com/workspace/chat/api/reference/rest/v1/spaces.messages
+type googleChatMessage struct {
+	Text    string             `json:"text,omitempty"`
+	CardsV2 []googleChatCardV2 `json:"cardsV2,omitempty"`
+	Thread  *googleChatThread  `json:"thread,omitempty"`
+}
+
+// googleChatCardV2 identifies a card of the message.
+type googleChatCardV2 struct {
+	CardID string         `json:"cardId"`
+	Card   googleChatCard `json:"card"`
+}
+
+// googleChatCard is a card with an optional header and sections of widgets.
+type googleChatCard struct {
+	Header   *googleChatHeader   `json:"header,omitempty"`
+	Sections []googleChatSection `json:"sections,omitempty"`
+}
+
+// googleChatHeader is the header of a card.
+type googleChatHeader struct {
+	Title    string `json:"title"`
+	Subtitle string `json:"subtitle,omitempty"`
+	ImageURL string `json:"imageUrl,omitempty"`
+}
+
+// googleChatSection is a list of widgets.
+type googleChatSection struct {
+	Widgets []map[string]interface{} `json:"widgets"`
+}
+
+// googleChatThread groups the messages sent with the same key into a thread.
+type googleChatThread struct {
+	ThreadKey string `json:"threadKey"`
+}
+
+// googleChatNotifier delivers the message to a Google Chat space as a card.
+type googleChatNotifier struct {
+	webhookURL string
+
+	// threadKey is the key of the thread to reply in, the thread is created by the first message sent with it.
+	threadKey string
+}
+
+// Notify implements Notifier.
+func (n googleChatNotifier) Notify(msg slack.Message) (slack.Response, error) {
+	webhookURL := n.webhookURL
+	if n.threadKey != "" {
+		u, err := url.Parse(webhookURL)
+		if err != nil {
+			return slack.Response{}, configurationErrorf("invalid Google Chat webhook URL: %s", err)
+		}
+		query := u.Query()
+		query.Set("messageReplyOption", "REPLY_MESSAGE_FALLBACK_TO_NEW_THREAD")
+		u.RawQuery = query.Encode()
+		webhookURL = u.String()
+	}
+
+	if _, _, err := postJSON(webhookURL, newGoogleChatMessage(msg, n.threadKey)); err != nil {
+		return slack.Response{}, err
+	}
+	return slack.Response{OK: true}, nil
+}
+
+// toGoogleChatHTML converts the links and bold text of Slack's mrkdwn format to the HTML subset of card texts.
+func toGoogleChatHTML(s string) string {
+	s = slackBoldPattern.ReplaceAllString(s, "<b>$1</b>")
+	s = slackLinkPattern.ReplaceAllStringFunc(s, func(link string) string {
+		m := slackLinkPattern.FindStringSubmatch(link)
+		if m[2] == "" {
+			m[2] = m[1]
+		}
+		return fmt.Sprintf(`<a href="%s">%s</a>`, m[1], m[2])
+	})
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+// newGoogleChatMessage converts the attachments of the message to cardsV2, the text is kept as it is,
+// Google Chat understands Slack's mrkdwn format.
+func newGoogleChatMessage(msg slack.Message, threadKey string) googleChatMessage {
+	gm := googleChatMessage{Text: msg.Text}
+	if threadKey != "" {
+		gm.Thread = &googleChatThread{ThreadKey: threadKey}
+	}
+
+	for i, a := range msg.Attachments {
+		var widgets []map[string]interface{}
+		if a.PreText != "" {
+			widgets = append(widgets, map[string]interface{}{"textParagraph": map[string]string{"text": toGoogleChatHTML(a.PreText)}})
+		}
+		if a.Text != "" {
+			widgets = append(widgets, map[string]interface{}{"textParagraph": map[string]string{"text": toGoogleChatHTML(a.Text)}})
+		}
+		for _, f := range a.Fields {
+			widgets = append(widgets, map[string]interface{}{"decoratedText": map[string]interface{}{
+				"topLabel": f.Title,
+				"text":     toGoogleChatHTML(f.Value),
+				"wrapText": true,
+			}})
+		}
+		if a.ImageURL != "" {
+			widgets = append(widgets, map[string]interface{}{"image": map[string]string{"imageUrl": a.ImageURL}})
+		}
+		links := a.Buttons
+		if a.Title != "" && a.TitleLink != "" {
+			links = append([]slack.Button{{Text: "Open", URL: a.TitleLink}}, links...)
+		}
+		if len(links) > 0 {
+			var buttons []map[string]interface{}
+			for _, b := range links {
+				buttons = append(buttons, map[string]interface{}{
+					"text":    b.Text,
+					"onClick": map[string]interface{}{"openLink": map[string]string{"url": b.URL}},
+				})
+			}
+			widgets = append(widgets, map[string]interface{}{"buttonList": map[string]interface{}{"buttons": buttons}})
+		}
+		if a.Footer != "" {
+			widgets = append(widgets, map[string]interface{}{"decoratedText": map[string]interface{}{
+				"text": fmt.Sprintf(`<font color="#80868b">%s</font>`, toGoogleChatHTML(a.Footer)),
+			}})
+		}
+
+		card := googleChatCard{}
+		if a.Title != "" {
+			card.Header = &googleChatHeader{Title: a.Title, Subtitle: a.AuthorName, ImageURL: a.ThumbURL}
+		}
+		if len(widgets) > 0 {
+			card.Sections = []googleChatSection{{Widgets: widgets}}
+		}
+		if card.Header == nil && card.Sections == nil {
+			continue
+		}
+		gm.CardsV2 = append(gm.CardsV2, googleChatCardV2{CardID: fmt.Sprintf("attachment-%d", i), Card: card})
+	}
+	return gm
+}
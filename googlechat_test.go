@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+)
+
+func Test_newGoogleChatMessage(t *testing.T) {
+	msg := slack.Message{
+		Text: "Build *#42* finished",
+		Attachments: []slack.Attachment{{
+			Title:      "Success",
+			TitleLink:  "https://app.bitrise.io/build/1",
+			AuthorName: "Bitrise",
+			Text:       "See <https://app.bitrise.io/build/1|the logs>",
+			Fields:     []slack.Field{{Title: "App", Value: "Demo"}},
+		}},
+	}
+
+	b, err := json.Marshal(newGoogleChatMessage(msg, "1405894322.002768"))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	want := `{"text":"Build *#42* finished","cardsV2":[{"cardId":"attachment-0","card":{` +
+		`"header":{"title":"Success","subtitle":"Bitrise"},"sections":[{"widgets":[` +
+		`{"textParagraph":{"text":"See \u003ca href=\"https://app.bitrise.io/build/1\"\u003ethe logs\u003c/a\u003e"}},` +
+		`{"decoratedText":{"text":"Demo","topLabel":"App","wrapText":true}},` +
+		`{"buttonList":{"buttons":[{"onClick":{"openLink":{"url":"https://app.bitrise.io/build/1"}},"text":"Open"}]}}` +
+		`]}]}}],"thread":{"threadKey":"1405894322.002768"}}`
+	if got := string(b); got != want {
+		t.Errorf("newGoogleChatMessage() = %v, want %v", got, want)
+	}
+}
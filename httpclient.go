@@ -0,0 +1,94 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// httpClient is used by every outgoing HTTP call of the step, configured in main.
+var httpClient = &http.Client{}
+
+// httpConfig configures the HTTP client.
+type httpConfig struct {
+	// CABundlePath is the path of a PEM file with additional trusted root certificates.
+	CABundlePath string
+
+	// ClientCertPath and ClientKeyPath are the paths of the PEM encoded client certificate and its key.
+	ClientCertPath string
+	ClientKeyPath  string
+
+	// ConnectTimeout limits establishing the connection, including the TLS handshake.
+	ConnectTimeout time.Duration
+
+	// Timeout limits the whole request, including reading the response body.
+	Timeout time.Duration
+}
+
+// newTLSConfig returns a TLS config trusting the system and the configured root certificates
+// and presenting the client certificate if set.
+func newTLSConfig(c httpConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+
+	if c.CABundlePath != "" {
+		pem, err := os.ReadFile(c.CABundlePath)
+		if err != nil {
+			return nil, configurationErrorf("failed to read the CA bundle: %s", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, configurationErrorf("the CA bundle (%s) does not contain any PEM encoded certificate", c.CABundlePath)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.ClientCertPath != "" || c.ClientKeyPath != "" {
+		if c.ClientCertPath == "" || c.ClientKeyPath == "" {
+			return nil, configurationErrorf("both the client certificate and its key are required")
+		}
+		cert, err := tls.LoadX509KeyPair(c.ClientCertPath, c.ClientKeyPath)
+		if err != nil {
+			return nil, configurationErrorf("failed to load the client certificate: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// newHTTPClient returns a client honouring the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables
+// and the TLS settings of the config.
+func newHTTPClient(c httpConfig) (*http.Client, error) {
+	tlsConfig, err := newTLSConfig(c)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         (&net.Dialer{Timeout: c.ConnectTimeout, KeepAlive: 30 * time.Second}).DialContext,
+		TLSClientConfig:     tlsConfig,
+		TLSHandshakeTimeout: c.ConnectTimeout,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	return &http.Client{Transport: transport, Timeout: c.Timeout}, nil
+}
+
+// parseTimeout parses a timeout input, empty meaning no timeout.
+func parseTimeout(name, s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, configurationErrorf("invalid %s: %s", name, s)
+	}
+	return d, nil
+}
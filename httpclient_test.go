@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_newHTTPClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"ok": true}`)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	caBundle := filepath.Join(dir, "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caBundle, cert, 0600); err != nil {
+		t.Fatal(err)
+	}
+	invalidBundle := filepath.Join(dir, "invalid.pem")
+	if err := os.WriteFile(invalidBundle, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		conf       httpConfig
+		wantErr    bool
+		wantReqErr bool
+	}{
+		{
+			name:       "Untrusted server",
+			conf:       httpConfig{Timeout: time.Second},
+			wantReqErr: true,
+		},
+		{
+			name: "Trusted by the CA bundle",
+			conf: httpConfig{CABundlePath: caBundle, ConnectTimeout: time.Second, Timeout: time.Second},
+		},
+		{
+			name:    "Invalid CA bundle",
+			conf:    httpConfig{CABundlePath: invalidBundle},
+			wantErr: true,
+		},
+		{
+			name:    "Client certificate without key",
+			conf:    httpConfig{ClientCertPath: caBundle},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := newHTTPClient(tt.conf)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newHTTPClient() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			resp, err := client.Get(server.URL)
+			if (err != nil) != tt.wantReqErr {
+				t.Fatalf("Get() error = %v, wantReqErr %v", err, tt.wantReqErr)
+			}
+			if err == nil {
+				resp.Body.Close()
+			}
+		})
+	}
+}
@@ -1,28 +1,41 @@
 package main
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/bitrise-io/go-utils/log"
-	"github.com/bitrise-io/go-utils/retry"
+	"github.com/bitrise-steplib/steps-slack-message/slack"
 	"github.com/bitrise-tools/go-steputils/stepconf"
-	"github.com/hashicorp/go-retryablehttp"
 )
 
 // Input ...
 type Input struct {
-	Debug         bool            `env:"is_debug_mode,opt[yes,no]"`
-	BuildAPIToken stepconf.Secret `env:"BITRISE_BUILD_API_TOKEN,required"`
-	BuildURL      string          `env:"BITRISE_BUILD_URL,required"`
+	Debug          bool            `env:"is_debug_mode,opt[yes,no]"`
+	PreflightCheck bool            `env:"preflight_check,opt[yes,no]"`
+	BuildAPIToken  stepconf.Secret `env:"BITRISE_BUILD_API_TOKEN,required"`
+	BuildURL       string          `env:"BITRISE_BUILD_URL,required"`
+	BuildNumber    string          `env:"BITRISE_BUILD_NUMBER"`
+	AppSlug        string          `env:"BITRISE_APP_SLUG"`
+	WorkflowID     string          `env:"BITRISE_TRIGGERED_WORKFLOW_ID"`
+	GitBranch      string          `env:"BITRISE_GIT_BRANCH"`
+
+	// Build report
+	AppTitle              string `env:"BITRISE_APP_TITLE"`
+	CommitSubject         string `env:"GIT_CLONE_COMMIT_MESSAGE_SUBJECT"`
+	CommitAuthor          string `env:"GIT_CLONE_COMMIT_AUTHOR_NAME"`
+	CommitHash            string `env:"GIT_CLONE_COMMIT_HASH"`
+	PullRequest           string `env:"BITRISE_PULL_REQUEST"`
+	RepositoryURL         string `env:"GIT_REPOSITORY_URL"`
+	BuildTriggerTimestamp string `env:"BITRISE_BUILD_TRIGGER_TIMESTAMP"`
 
 	// Message
+	Backend               string          `env:"backend,opt[slack,slack_workflow,teams,discord,google_chat,mattermost,generic_webhook,email]"`
 	WebhookURL            stepconf.Secret `env:"webhook_url"`
 	WebhookURLOnError     stepconf.Secret `env:"webhook_url_on_error"`
 	APIToken              stepconf.Secret `env:"api_token"`
@@ -46,6 +59,43 @@ type Input struct {
 	TsOnError             string          `env:"ts_on_error"`
 	ReplyBroadcast        bool            `env:"reply_broadcast,opt[yes,no]"`
 	ReplyBroadcastOnError bool            `env:"reply_broadcast_on_error,opt[yes,no]"`
+	AutoJoinChannel       bool            `env:"auto_join_channel,opt[yes,no]"`
+
+	// Mattermost
+	MattermostPriority        string `env:"mattermost_priority"`
+	MattermostPriorityOnError string `env:"mattermost_priority_on_error"`
+
+	// Slack workflow
+	WorkflowVariables string `env:"workflow_variables"`
+
+	// Email
+	SMTPHost               string          `env:"smtp_host"`
+	SMTPPort               string          `env:"smtp_port"`
+	SMTPSecurity           string          `env:"smtp_security,opt[starttls,tls,none]"`
+	SMTPUsername           string          `env:"smtp_username"`
+	SMTPPassword           stepconf.Secret `env:"smtp_password"`
+	EmailFrom              string          `env:"email_from"`
+	EmailRecipients        string          `env:"email_recipients"`
+	EmailRecipientsOnError string          `env:"email_recipients_on_error"`
+
+	// Generic webhook
+	WebhookMethod                 string          `env:"webhook_method"`
+	WebhookHeaders                stepconf.Secret `env:"webhook_headers"`
+	WebhookPayloadTemplate        string          `env:"webhook_payload_template"`
+	WebhookPayloadTemplateOnError string          `env:"webhook_payload_template_on_error"`
+
+	// Block Kit
+	Preset             string `env:"preset,opt[none,build_report]"`
+	Layout             string `env:"layout,opt[attachment,blocks]"`
+	LayoutColorBar     bool   `env:"layout_color_bar,opt[yes,no]"`
+	BlockHeader        string `env:"block_header"`
+	BlockHeaderOnError string `env:"block_header_on_error"`
+	BlockText          string `env:"block_text"`
+	BlockTextOnError   string `env:"block_text_on_error"`
+	BlockFields        string `env:"block_fields"`
+	BlockContext       string `env:"block_context"`
+	BlockImageURL      string `env:"block_image_url"`
+	BlockButtons       string `env:"block_buttons"`
 
 	// Attachment
 	Color             string `env:"color,required"`
@@ -74,17 +124,66 @@ type Input struct {
 	BuildStatus         string `env:"build_status"`
 	PipelineBuildStatus string `env:"pipeline_build_status"`
 
+	// Escalation
+	EscalationRules string `env:"escalation_rules"`
+
+	// Approval
+	ApprovalTimeout string `env:"approval_timeout"`
+	ApprovalUsers   string `env:"approval_users"`
+	ApproveReaction string `env:"approve_reaction"`
+	RejectReaction  string `env:"reject_reaction"`
+	ApproveKeyword  string `env:"approve_keyword"`
+	RejectKeyword   string `env:"reject_keyword"`
+
+	// Scheduling
+	DeliveryWindow           string `env:"delivery_window"`
+	CancelScheduledMessageID string `env:"cancel_scheduled_message_id"`
+
+	// Routing
+	RoutingRules    string `env:"routing_rules"`
+	RoutingTimezone string `env:"routing_timezone"`
+
+	// Ephemeral
+	EphemeralUser        string `env:"ephemeral_user"`
+	EphemeralUserOnError string `env:"ephemeral_user_on_error"`
+
+	// Secret scanning
+	SecretScanning string `env:"secret_scanning,opt[off,redact,block]"`
+
+	// Network
+	CABundlePath   string `env:"ca_bundle_path"`
+	ClientCertPath string `env:"client_cert_path"`
+	ClientKeyPath  string `env:"client_key_path"`
+	ConnectTimeout string `env:"connect_timeout"`
+	Timeout        string `env:"timeout"`
+
+	// Error handling
+	NonFatalErrors string `env:"non_fatal_errors"`
+
 	// Step Outputs
 	ThreadTsOutputVariableName string `env:"output_thread_ts"`
 }
 
 type config struct {
-	Debug bool `env:"is_debug_mode,opt[yes,no]"`
+	Debug          bool `env:"is_debug_mode,opt[yes,no]"`
+	PreflightCheck bool
+	Backend        string
+	Success        bool
+	BuildURL       string
+	BuildAPIToken  stepconf.Secret
+	BuildNumber    string
+	AppSlug        string
+	WorkflowID     string
+	GitBranch      string
+
+	// Credentials are tried in order until the message is delivered.
+	Credentials []credential
 
 	// Message
 	APIToken       stepconf.Secret `env:"api_token"`
 	WebhookURL     string
 	Channel        string
+	SuccessChannel string
 	Text           string
 	IconEmoji      string
 	IconURL        string
@@ -94,8 +193,14 @@ type config struct {
 	ReplyBroadcast bool
 	LinkNames      bool `env:"link_names,opt[yes,no]"`
 
+	AutoJoinChannel bool
+
 	// Blocks
-	Blocks string
+	Blocks         string
+	BlockBuilder   blockBuilder
+	Preset         string
+	Layout         string
+	LayoutColorBar bool
 
 	// Attachment
 	Color      string
@@ -112,6 +217,48 @@ type config struct {
 	Fields     string `env:"fields"`
 	Buttons    string `env:"buttons"`
 
+	// Escalation
+	EscalationRules []escalationRule
+	Metadata        *slack.Metadata
+
+	// Approval
+	ApprovalTimeout time.Duration
+	ApprovalUsers   []string
+	ApproveReaction string
+	RejectReaction  string
+	ApproveKeyword  string
+	RejectKeyword   string
+
+	// Scheduling
+	DeliveryWindow           *deliveryWindow
+	PostAt                   int64
+	CancelScheduledMessageID string
+
+	// Routing
+	RoutingRules    []routingRule
+	RoutingLocation *time.Location
+
+	// Mattermost
+	MattermostPriority string
+
+	// Slack workflow
+	WorkflowVariables [][2]string
+
+	// Email
+	Email emailConfig
+
+	// Generic webhook
+	GenericWebhook genericWebhook
+
+	// Ephemeral
+	EphemeralUser string
+
+	// Secret scanning
+	SecretScanning string
+
+	// Network
+	HTTP httpConfig
+
 	// Step Outputs
 	ThreadTsOutputVariableName string `env:"output_thread_ts"`
 }
@@ -121,12 +268,12 @@ func ensureNewlines(s string) string {
 	return strings.Replace(s, "\\n", "\n", -1)
 }
 
-func newMessage(c config) Message {
-	msg := Message{
+func newMessage(c config) slack.Message {
+	msg := slack.Message{
 		Channel: strings.TrimSpace(c.Channel),
 		Text:    c.Text,
 		Blocks:  c.Blocks,
-		Attachments: []Attachment{{
+		Attachments: []slack.Attachment{{
 			Fallback:   ensureNewlines(c.Message),
 			Color:      c.Color,
 			PreText:    c.PreText,
@@ -148,127 +295,102 @@ func newMessage(c config) Message {
 		ThreadTs:       c.ThreadTs,
 		Ts:             c.Ts,
 		ReplyBroadcast: c.ReplyBroadcast,
+		Metadata:       c.Metadata,
+		PostAt:         c.PostAt,
 	}
 	if c.TimeStamp {
 		msg.Attachments[0].TimeStamp = int(time.Now().Unix())
 	}
+	if c.Preset == presetBuildReport {
+		// The report replaces the attachment, only its colour is kept to frame the report.
+		msg.Attachments = []slack.Attachment{{
+			Fallback: c.BlockBuilder.Header,
+			Color:    c.Color,
+			Blocks:   []byte(c.BlockBuilder.blocksJSON()),
+		}}
+		if msg.Text == "" {
+			msg.Text = c.BlockBuilder.Header
+		}
+		return msg
+	}
+	if msg.Blocks == "" && !c.BlockBuilder.isEmpty() {
+		msg.Blocks = c.BlockBuilder.blocksJSON()
+	}
+	if isSlackBackend(c) {
+		applyLayout(&msg, c.Layout, c.LayoutColorBar)
+	}
 	return msg
 }
 
 func getWebhookURL(buildURL string, id string, token string) (string, error) {
-	var webhookData struct {
-		WebhookURL string `json:"webhook_url"`
-	}
-	siURL := fmt.Sprintf("%s/integrations/slack/%s", buildURL, id)
-
-	req, err := retryablehttp.NewRequest("GET", siURL, http.NoBody)
-	if err != nil {
-		return "", err
+	client := slack.NewIntegrationClient(slack.Integration{BuildURL: buildURL, ID: id, BuildAPIToken: token}, slack.WithHTTPClient(httpClient))
+	webhookURL, err := client.WebhookURL(context.Background())
+	var statusErr *slack.StatusError
+	if errors.As(err, &statusErr) {
+		return "", statusError(statusErr.StatusCode, errorClassConfiguration, err)
 	}
-	req.Header.Add("Build-Api-Token", token)
-	client := retry.NewHTTPClient()
-
-	resp, err := client.Do(req)
-
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode == http.StatusOK {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return "", err
-		}
-		if err = json.Unmarshal(body, &webhookData); err != nil {
-			return "", err
-		}
-	} else {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return "", err
-		}
-		return "", fmt.Errorf("server error, status: %s\nresponse: %s", resp.Status, string(body))
-	}
-	return webhookData.WebhookURL, nil
+	registerSecret(webhookURL)
+	return webhookURL, nil
 }
 
-// postMessage sends a message to a channel.
-func postMessage(conf config, msg Message) error {
-	b, err := json.Marshal(msg)
-	if err != nil {
-		return err
+// postMessage sends a message to a channel, or updates it if the config has a ts.
+func postMessage(conf config, msg slack.Message) (slack.Response, error) {
+	if isWorkflowTrigger(conf.WebhookURL) {
+		return triggerWorkflow(conf.WebhookURL, workflowVariables(conf, msg))
 	}
-	log.Debugf("Request to Slack: %s\n", b)
 
-	url := strings.TrimSpace(conf.WebhookURL)
-	ts := strings.TrimSpace(conf.Ts)
-
-	if url == "" {
-		if ts == "" {
-			url = "https://slack.com/api/chat.postMessage"
-		} else {
-			url = "https://slack.com/api/chat.update"
-		}
+	client := newSlackClient(conf)
+	post := client.Post
+	if strings.TrimSpace(conf.Ts) != "" {
+		post = client.Update
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewReader(b))
-	if err != nil {
-		return err
-	}
-	req.Header.Add("Content-Type", "application/json; charset=utf-8")
+	return post(context.Background(), msg)
+}
 
-	if string(conf.APIToken) != "" {
-		req.Header.Add("Authorization", "Bearer "+string(conf.APIToken))
+// deliver sends the message, joining the target channel and retrying once
+// if the bot is not a member of it.
+func deliver(conf config, msg slack.Message) (slack.Response, error) {
+	send := postMessage
+	if conf.EphemeralUser != "" {
+		send = postEphemeral
 	}
 
-	client := &http.Client{}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send the request: %s", err)
+	response, err := send(conf, msg)
+	var apiErr *slack.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "not_in_channel" {
+		return response, err
 	}
-	defer func() {
-		if cerr := resp.Body.Close(); err == nil {
-			err = cerr
-		}
-	}()
-
-	if resp.StatusCode != http.StatusOK {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return fmt.Errorf("server error: %s, failed to read response: %s", resp.Status, err)
-		}
-		return fmt.Errorf("server error: %s, response: %s", resp.Status, body)
+	if !conf.AutoJoinChannel {
+		return response, fmt.Errorf("%w, invite the bot to the channel or enable auto_join_channel", err)
 	}
 
-	if err := exportOutputs(&conf, resp); err != nil {
-		return fmt.Errorf("failed to export outputs: %s", err)
+	log.Warnf("The bot is not a member of %s, joining the channel", msg.Channel)
+	if err := joinChannel(conf.APIToken, msg.Channel); err != nil {
+		return response, err
 	}
-
-	return nil
+	return send(conf, msg)
 }
 
 func validate(inp *Input) error {
-	if inp.APIToken == "" && inp.WebhookURL == "" && inp.IntegrationID == "" {
-		return fmt.Errorf("All of Integration ID, API Token and WebhookURL are empty. You need to provide one of them. If you want to use incoming webhooks provide the webhook url. If you want to use a bot to send a message provide the bot API token. If you want to use a configured workspace integration use its ID.")
+	if inp.Backend != backendEmail && inp.APIToken == "" && inp.WebhookURL == "" && inp.IntegrationID == "" {
+		return configurationErrorf("All of Integration ID, API Token and WebhookURL are empty. You need to provide one of them. If you want to use incoming webhooks provide the webhook url. If you want to use a bot to send a message provide the bot API token. If you want to use a configured workspace integration use its ID.")
 	}
 
-	if inp.IntegrationID != "" {
-		if inp.APIToken != "" {
-			log.Warnf("Both API Token and Integration ID are provided. Ignoring API Token.")
-			inp.APIToken = ""
-		}
-		if inp.WebhookURL != "" {
-			log.Warnf("Both WebhookURL and Integration ID are provided. Ignoring WebhookURL.")
-			inp.WebhookURL = ""
-		}
-		return nil
+	if inp.Blocks != "" && (inp.BlockHeader != "" || inp.BlockHeaderOnError != "" || inp.BlockText != "" || inp.BlockTextOnError != "" ||
+		inp.BlockFields != "" || inp.BlockContext != "" || inp.BlockImageURL != "" || inp.BlockButtons != "") {
+		return configurationErrorf("The blocks input can not be used together with the Block Kit builder inputs (block_header, block_text, block_fields, block_context, block_image_url and block_buttons).")
 	}
 
-	if inp.APIToken != "" && inp.WebhookURL != "" {
-		log.Warnf("Both API Token and WebhookURL are provided. Using the API Token")
-		inp.WebhookURL = ""
+	if inp.Blocks != "" && inp.Preset == presetBuildReport {
+		return configurationErrorf("The blocks input can not be used together with the build report preset, override its pieces with the Block Kit builder inputs instead.")
+	}
 
+	if inp.RoutingRules != "" && inp.RoutingTimezone == "" {
+		return configurationErrorf("Routing rules require an explicit timezone, set the routing_timezone input (eg. Europe/Berlin).")
 	}
 	return nil
 }
@@ -288,18 +410,24 @@ func parseInputIntoConfig(inp *Input) (config, error) {
 	}
 	var integrationID = selectValue(inp.IntegrationID, inp.IntegrationIDOnError)
 	var webhookURL = selectValue(string(inp.WebhookURL), string(inp.WebhookURLOnError))
-	if integrationID != "" {
-		var err error
-		webhookURL, err = getWebhookURL(inp.BuildURL, integrationID, string(inp.BuildAPIToken))
-		if err != nil {
-			return config{}, err
-		}
+	var fallbackWebhookURL = string(inp.WebhookURLOnError)
+	if webhookURL == fallbackWebhookURL {
+		fallbackWebhookURL = string(inp.WebhookURL)
 	}
 
 	var config = config{
 		Debug:                      inp.Debug,
+		PreflightCheck:             inp.PreflightCheck,
+		Backend:                    inp.Backend,
+		Success:                    success,
+		BuildURL:                   inp.BuildURL,
+		BuildAPIToken:              inp.BuildAPIToken,
+		BuildNumber:                inp.BuildNumber,
+		AppSlug:                    inp.AppSlug,
+		WorkflowID:                 inp.WorkflowID,
+		GitBranch:                  inp.GitBranch,
+		Credentials:                newCredentialChain(integrationID, inp.APIToken, stepconf.Secret(webhookURL), stepconf.Secret(fallbackWebhookURL)),
 		APIToken:                   inp.APIToken,
-		WebhookURL:                 webhookURL,
 		Channel:                    selectValue(inp.Channel, inp.ChannelOnError),
 		Text:                       selectValue(inp.Text, inp.TextOnError),
 		Blocks:                     inp.Blocks,
@@ -309,6 +437,7 @@ func parseInputIntoConfig(inp *Input) (config, error) {
 		ThreadTs:                   selectValue(inp.ThreadTs, inp.ThreadTsOnError),
 		ReplyBroadcast:             (success && inp.ReplyBroadcast) || (!success && inp.ReplyBroadcastOnError),
 		LinkNames:                  inp.LinkNames,
+		AutoJoinChannel:            inp.AutoJoinChannel,
 		Color:                      selectValue(inp.Color, inp.ColorOnError),
 		PreText:                    selectValue(inp.PreText, inp.PreTextOnError),
 		Title:                      selectValue(inp.Title, inp.TitleOnError),
@@ -322,8 +451,133 @@ func parseInputIntoConfig(inp *Input) (config, error) {
 		TimeStamp:                  inp.TimeStamp,
 		Fields:                     inp.Fields,
 		Buttons:                    inp.Buttons,
+		WorkflowVariables:          pairs(inp.WorkflowVariables),
 		ThreadTsOutputVariableName: inp.ThreadTsOutputVariableName,
 		Ts:                         selectValue(inp.Ts, inp.TsOnError),
+		EphemeralUser:              strings.TrimSpace(selectValue(inp.EphemeralUser, inp.EphemeralUserOnError)),
+		SecretScanning:             inp.SecretScanning,
+	}
+
+	config.Layout = inp.Layout
+	config.LayoutColorBar = inp.LayoutColorBar
+	if inp.Layout == layoutBlocks && !inp.LayoutColorBar && inp.Blocks != "" {
+		var blocks []json.RawMessage
+		if err := json.Unmarshal([]byte(inp.Blocks), &blocks); err != nil {
+			return config, configurationErrorf("The blocks layout appends the attachment to the blocks input, which is not a valid JSON array: %s", err)
+		}
+	}
+	// The success messages are looked up by the escalation rules to reset the streak of failures.
+	config.SuccessChannel = strings.TrimSpace(inp.Channel)
+	config.BlockBuilder = blockBuilder{
+		Header:   selectValue(inp.BlockHeader, inp.BlockHeaderOnError),
+		Text:     selectValue(inp.BlockText, inp.BlockTextOnError),
+		Fields:   inp.BlockFields,
+		Context:  inp.BlockContext,
+		ImageURL: inp.BlockImageURL,
+		Buttons:  inp.BlockButtons,
+	}
+	if inp.Preset == presetBuildReport {
+		config.Preset = presetBuildReport
+		report := buildReport{
+			Success:          success,
+			AppTitle:         inp.AppTitle,
+			WorkflowID:       inp.WorkflowID,
+			Branch:           inp.GitBranch,
+			CommitSubject:    inp.CommitSubject,
+			CommitAuthor:     inp.CommitAuthor,
+			CommitHash:       inp.CommitHash,
+			PullRequest:      inp.PullRequest,
+			RepositoryURL:    inp.RepositoryURL,
+			BuildNumber:      inp.BuildNumber,
+			BuildURL:         inp.BuildURL,
+			TriggerTimestamp: inp.BuildTriggerTimestamp,
+		}
+		config.BlockBuilder = report.blocks(time.Now()).override(config.BlockBuilder)
+	}
+
+	if inp.EscalationRules != "" {
+		rules, err := parseEscalationRules(inp.EscalationRules)
+		if err != nil {
+			return config, err
+		}
+		config.EscalationRules = rules
+		config.Metadata = buildMetadata(inp, success)
+	}
+
+	if inp.ApprovalTimeout != "" {
+		timeout, err := time.ParseDuration(inp.ApprovalTimeout)
+		if err != nil {
+			return config, configurationErrorf("invalid approval timeout: %s", err)
+		}
+		config.ApprovalTimeout = timeout
+		config.ApprovalUsers = parseList(inp.ApprovalUsers)
+		config.ApproveReaction = strings.Trim(inp.ApproveReaction, ":")
+		config.RejectReaction = strings.Trim(inp.RejectReaction, ":")
+		config.ApproveKeyword = strings.TrimSpace(inp.ApproveKeyword)
+		config.RejectKeyword = strings.TrimSpace(inp.RejectKeyword)
+	}
+
+	if inp.DeliveryWindow != "" {
+		window, err := parseDeliveryWindow(inp.DeliveryWindow)
+		if err != nil {
+			return config, err
+		}
+		config.DeliveryWindow = &window
+	}
+	config.CancelScheduledMessageID = strings.TrimSpace(inp.CancelScheduledMessageID)
+
+	config.MattermostPriority = strings.ToLower(strings.TrimSpace(selectValue(inp.MattermostPriority, inp.MattermostPriorityOnError)))
+	if !isMattermostPriority(config.MattermostPriority) {
+		return config, configurationErrorf("invalid Mattermost priority: %s", config.MattermostPriority)
+	}
+
+	if config.Backend == backendEmail {
+		config.Email = emailConfig{
+			Host:       strings.TrimSpace(inp.SMTPHost),
+			Port:       strings.TrimSpace(inp.SMTPPort),
+			Security:   inp.SMTPSecurity,
+			Username:   strings.TrimSpace(inp.SMTPUsername),
+			Password:   inp.SMTPPassword,
+			From:       strings.TrimSpace(inp.EmailFrom),
+			Recipients: parseList(selectValue(inp.EmailRecipients, inp.EmailRecipientsOnError)),
+		}
+		if config.Email.Port == "" {
+			config.Email.Port = "587"
+		}
+	}
+
+	if config.Backend == backendGenericWebhook {
+		webhook, err := parseGenericWebhook(inp.WebhookMethod, string(inp.WebhookHeaders), selectValue(inp.WebhookPayloadTemplate, inp.WebhookPayloadTemplateOnError))
+		if err != nil {
+			return config, err
+		}
+		config.GenericWebhook = webhook
+	}
+
+	config.HTTP = httpConfig{
+		CABundlePath:   strings.TrimSpace(inp.CABundlePath),
+		ClientCertPath: strings.TrimSpace(inp.ClientCertPath),
+		ClientKeyPath:  strings.TrimSpace(inp.ClientKeyPath),
+	}
+	var err error
+	if config.HTTP.ConnectTimeout, err = parseTimeout("connect timeout", inp.ConnectTimeout); err != nil {
+		return config, err
+	}
+	if config.HTTP.Timeout, err = parseTimeout("timeout", inp.Timeout); err != nil {
+		return config, err
+	}
+
+	if inp.RoutingRules != "" {
+		rules, err := parseRoutingRules(inp.RoutingRules)
+		if err != nil {
+			return config, err
+		}
+		location, err := time.LoadLocation(inp.RoutingTimezone)
+		if err != nil {
+			return config, configurationErrorf("invalid routing timezone: %s", err)
+		}
+		config.RoutingRules = rules
+		config.RoutingLocation = location
 	}
 	return config, nil
 
@@ -335,25 +589,97 @@ func main() {
 		log.Errorf("Error: %s\n", err)
 		os.Exit(1)
 	}
+	log.SetOutWriter(redactingWriter{w: os.Stdout})
+	registerSecrets(input)
 	stepconf.Print(input)
 	log.SetEnableDebugLog(input.Debug)
 
-	if err := validate(&input); err != nil {
+	nonFatal, err := parseErrorClasses(input.NonFatalErrors)
+	if err != nil {
 		log.Errorf("Error: %s\n", err)
 		os.Exit(1)
 	}
 
+	if err := validate(&input); err != nil {
+		exitWithError(nonFatal, err)
+	}
+
 	config, err := parseInputIntoConfig(&input)
 	if err != nil {
-		log.Errorf("Error: %s\n", err)
-		os.Exit(1)
+		exitWithError(nonFatal, err)
+	}
+
+	if err := checkCompatibility(config); err != nil {
+		exitWithError(nonFatal, err)
+	}
+
+	if httpClient, err = newHTTPClient(config.HTTP); err != nil {
+		exitWithError(nonFatal, err)
+	}
+
+	if config.PreflightCheck {
+		if err := preflight(config); err != nil {
+			log.Errorf("Error: %s\n", err)
+			os.Exit(1)
+		}
+		log.Donef("\nPreflight check passed! 🚀\n")
+		return
+	}
+
+	if config.CancelScheduledMessageID != "" {
+		if err := deleteScheduledMessage(config); err != nil {
+			exitWithError(nonFatal, err)
+		}
+		log.Donef("\nScheduled Slack message successfully cancelled! 🚀\n")
+		return
+	}
+
+	if !route(&config, time.Now()) {
+		log.Warnf("The message is dropped by the routing rules, not sending it.")
+		return
+	}
+
+	if isSlackBackend(config) {
+		if err := escalate(&config); err != nil {
+			log.Warnf("Failed to apply escalation rules, sending the message without escalation: %s", err)
+		}
+
+		if err := resolveRecipients(&config); err != nil {
+			exitWithError(nonFatal, err)
+		}
+
+		resolveChannelID(&config)
+
+		schedule(&config, time.Now())
 	}
 
 	msg := newMessage(config)
-	if err := postMessage(config, msg); err != nil {
-		log.Errorf("Error: %s", err)
-		os.Exit(1)
+	if err := scanMessage(&msg, config.SecretScanning); err != nil {
+		exitWithError(nonFatal, err)
+	}
+
+	notifier, err := newNotifier(config)
+	if err != nil {
+		exitWithError(nonFatal, err)
+	}
+	response, err := notifier.Notify(msg)
+	if err != nil {
+		exitWithError(nonFatal, err)
 	}
 
-	log.Donef("\nSlack message successfully sent! 🚀\n")
+	switch {
+	case response.ScheduledMessageID != "":
+		log.Donef("\nSlack message successfully scheduled! 🚀\n")
+	case !isSlackBackend(config):
+		log.Donef("\nMessage successfully sent! 🚀\n")
+	default:
+		log.Donef("\nSlack message successfully sent! 🚀\n")
+	}
+
+	if config.ApprovalTimeout > 0 {
+		if err := requireApproval(config, response); err != nil {
+			log.Errorf("Error: %s", err)
+			os.Exit(1)
+		}
+	}
 }
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+)
+
+// mattermostPriorities are the valid message priorities, empty meaning the standard priority.
+var mattermostPriorities = []string{"", "important", "urgent"}
+
+// isMattermostPriority tells whether p is a valid message priority.
+func isMattermostPriority(p string) bool {
+	for _, priority := range mattermostPriorities {
+		if p == priority {
+			return true
+		}
+	}
+	return false
+}
+
+// mattermostMessage is the Slack compatible payload of a Mattermost incoming webhook.
+// See also: https://developers.mattermost.com/integrate/webhooks/incoming/
+type mattermostMessage struct {
+	Channel     string              `json:"channel,omitempty"`
+	Text        string              `json:"text,omitempty"`
+	Username    string              `json:"username,omitempty"`
+	IconURL     string              `json:"icon_url,omitempty"`
+	IconEmoji   string              `json:"icon_emoji,omitempty"`
+	Attachments []slack.Attachment  `json:"attachments,omitempty"`
+	Props       map[string]string   `json:"props,omitempty"`
+	Priority    *mattermostPriority `json:"priority,omitempty"`
+}
+
+// mattermostPriority marks the message as important or urgent.
+type mattermostPriority struct {
+	Priority     string `json:"priority"`
+	RequestedAck bool   `json:"requested_ack,omitempty"`
+}
+
+// mattermostNotifier delivers the message to a Mattermost incoming webhook.
+type mattermostNotifier struct {
+	webhookURL string
+	priority   string
+}
+
+// Notify implements Notifier.
+func (n mattermostNotifier) Notify(msg slack.Message) (slack.Response, error) {
+	if _, _, err := postJSON(n.webhookURL, newMattermostMessage(msg, n.priority)); err != nil {
+		return slack.Response{}, err
+	}
+	return slack.Response{OK: true}, nil
+}
+
+// newMattermostMessage converts the message to Mattermost's Markdown and Slack compatible attachments.
+// Link buttons are not supported by incoming webhooks, they are added to the text of the attachment instead.
+// The fields are also summarized in the card prop, shown in the message's info panel.
+func newMattermostMessage(msg slack.Message, priority string) mattermostMessage {
+	mm := mattermostMessage{
+		Channel:   strings.TrimPrefix(msg.Channel, "#"),
+		Text:      toMarkdown(msg.Text),
+		Username:  msg.Username,
+		IconURL:   msg.IconURL,
+		IconEmoji: msg.IconEmoji,
+	}
+	if priority != "" {
+		mm.Priority = &mattermostPriority{Priority: priority, RequestedAck: priority == "urgent"}
+	}
+
+	var card []string
+	for _, a := range msg.Attachments {
+		a.Fallback = toMarkdown(a.Fallback)
+		a.PreText = toMarkdown(a.PreText)
+		a.Text = toMarkdown(a.Text)
+
+		fields := make([]slack.Field, len(a.Fields))
+		for i, f := range a.Fields {
+			fields[i] = slack.Field{Title: f.Title, Value: toMarkdown(f.Value)}
+			card = append(card, fmt.Sprintf("**%s**: %s", f.Title, fields[i].Value))
+		}
+		a.Fields = fields
+
+		var links []string
+		for _, b := range a.Buttons {
+			links = append(links, fmt.Sprintf("[%s](%s)", b.Text, b.URL))
+		}
+		if len(links) > 0 {
+			a.Text = strings.TrimSpace(a.Text + "\n\n" + strings.Join(links, " · "))
+		}
+		a.Buttons = nil
+
+		mm.Attachments = append(mm.Attachments, a)
+	}
+	if len(card) > 0 {
+		mm.Props = map[string]string{"card": strings.Join(card, "\n")}
+	}
+	return mm
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+)
+
+func Test_newMattermostMessage(t *testing.T) {
+	msg := slack.Message{
+		Channel: "#builds",
+		Text:    "Build *#42* failed",
+		Attachments: []slack.Attachment{{
+			Color:   "#f0741f",
+			Text:    "See <https://app.bitrise.io/build/1|the logs>",
+			Fields:  []slack.Field{{Title: "App", Value: "Demo"}},
+			Buttons: []slack.Button{{Text: "Open", URL: "https://app.bitrise.io"}},
+		}},
+	}
+
+	want := mattermostMessage{
+		Channel: "builds",
+		Text:    "Build **#42** failed",
+		Attachments: []slack.Attachment{{
+			Color:  "#f0741f",
+			Text:   "See [the logs](https://app.bitrise.io/build/1)\n\n[Open](https://app.bitrise.io)",
+			Fields: []slack.Field{{Title: "App", Value: "Demo"}},
+		}},
+		Props:    map[string]string{"card": "**App**: Demo"},
+		Priority: &mattermostPriority{Priority: "urgent", RequestedAck: true},
+	}
+	if got := newMattermostMessage(msg, "urgent"); !reflect.DeepEqual(got, want) {
+		t.Errorf("newMattermostMessage() = %+v, want %+v", got, want)
+	}
+}
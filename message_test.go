@@ -3,18 +3,20 @@ package main
 import (
 	"reflect"
 	"testing"
+
+	"github.com/bitrise-steplib/steps-slack-message/slack"
 )
 
 func Test_parseFields(t *testing.T) {
 	tests := []struct {
 		name   string
 		s      string
-		wantFs []Field
+		wantFs []slack.Field
 	}{
 		{
 			name:   "Newline in release notes",
 			s:      "Release notes|line1\\nline2",
-			wantFs: []Field{{Title: "Release notes", Value: "line1\nline2"}},
+			wantFs: []slack.Field{{Title: "Release notes", Value: "line1\nline2"}},
 		},
 	}
 	for _, tt := range tests {
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+)
+
+// Backends the message can be delivered to.
+const (
+	backendSlack          = "slack"
+	backendSlackWorkflow  = "slack_workflow"
+	backendTeams          = "teams"
+	backendDiscord        = "discord"
+	backendGoogleChat     = "google_chat"
+	backendMattermost     = "mattermost"
+	backendGenericWebhook = "generic_webhook"
+	backendEmail          = "email"
+)
+
+// Notifier delivers the message built from the inputs to a chat service.
+//
+// The Slack message is the shared model of every backend on purpose: the inputs describe a Slack message
+// (text, attachment, fields, buttons), and each backend converts it to its own format rather than going
+// through a neutral model which would lose the Slack specific parts the default backend needs.
+type Notifier interface {
+	// Notify sends the message. Only the Slack backend fills the response, the others return an empty one.
+	Notify(msg slack.Message) (slack.Response, error)
+}
+
+// slackNotifier delivers the message to Slack with the credential chain of the config.
+type slackNotifier struct {
+	conf config
+}
+
+// Notify implements Notifier.
+func (n slackNotifier) Notify(msg slack.Message) (slack.Response, error) {
+	return send(n.conf, msg)
+}
+
+// isSlackBackend tells whether the message is delivered to Slack, the default backend.
+func isSlackBackend(conf config) bool {
+	return conf.Backend == "" || conf.Backend == backendSlack
+}
+
+// newNotifier returns the Notifier of the configured backend.
+func newNotifier(conf config) (Notifier, error) {
+	if isSlackBackend(conf) {
+		return slackNotifier{conf: conf}, nil
+	}
+
+	switch conf.Backend {
+	case backendSlackWorkflow:
+		return workflowNotifier{webhookURL: webhookURL(conf), conf: conf}, nil
+	case backendTeams:
+		return teamsNotifier{webhookURL: webhookURL(conf)}, nil
+	case backendDiscord:
+		return discordNotifier{webhookURL: webhookURL(conf), threadID: strings.TrimSpace(conf.ThreadTs)}, nil
+	case backendGoogleChat:
+		return googleChatNotifier{webhookURL: webhookURL(conf), threadKey: strings.TrimSpace(conf.ThreadTs)}, nil
+	case backendMattermost:
+		return mattermostNotifier{webhookURL: webhookURL(conf), priority: conf.MattermostPriority}, nil
+	case backendGenericWebhook:
+		return genericWebhookNotifier{url: webhookURL(conf), webhook: conf.GenericWebhook, conf: conf}, nil
+	case backendEmail:
+		tlsConfig, err := newTLSConfig(conf.HTTP)
+		if err != nil {
+			return nil, err
+		}
+		return emailNotifier{email: conf.Email, success: conf.Success, tls: tlsConfig, connectTimeout: conf.HTTP.ConnectTimeout, timeout: conf.HTTP.Timeout}, nil
+	}
+	return nil, configurationErrorf("unknown backend: %s", conf.Backend)
+}
+
+// webhookURL returns the first webhook URL of the credential chain, used by the backends other than Slack.
+func webhookURL(conf config) string {
+	for _, cred := range conf.Credentials {
+		if cred.WebhookURL != "" {
+			return string(cred.WebhookURL)
+		}
+	}
+	return ""
+}
@@ -1,41 +1,33 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
-	"net/http"
 	"os/exec"
-	"strings"
 
 	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-steplib/steps-slack-message/slack"
 )
 
-// SendMessageResponse is the response from Slack POST
-type SendMessageResponse struct {
-	/// The Thread Timestamp
-	Timestamp string `json:"ts"`
-}
-
-/// Export the output variables after a successful response
-func exportOutputs(conf *config, resp *http.Response) error {
+// exportOutputs exports the output variables after a successful response.
+func exportOutputs(conf *config, response slack.Response) error {
 
-	if !isRequestingOutput(conf) {
-		log.Debugf("Not requesting any outputs")
-		return nil
+	if response.Channel != "" {
+		log.Debugf("Exporting output: %s=%s\n", channelIDOutputKey, response.Channel)
+		if err := exportEnvVariable(channelIDOutputKey, response.Channel); err != nil {
+			return err
+		}
 	}
 
-	isWebhook := strings.TrimSpace(conf.WebhookURL) != ""
-
-	// Slack webhooks do not return any useful response information
-	if isWebhook {
-		return fmt.Errorf("For output support, do not submit a WebHook URL")
+	if response.ScheduledMessageID != "" {
+		log.Debugf("Exporting output: %s=%s\n", scheduledMessageIDOutputKey, response.ScheduledMessageID)
+		if err := exportEnvVariable(scheduledMessageIDOutputKey, response.ScheduledMessageID); err != nil {
+			return err
+		}
 	}
 
-	var response SendMessageResponse
-	parseError := json.NewDecoder(resp.Body).Decode(&response)
-	if parseError != nil {
-		// here we want to fail, because the user is expecting an output
-		return fmt.Errorf("Failed to parse response: %s", parseError)
+	if !isRequestingOutput(conf) {
+		log.Debugf("Not requesting any outputs")
+		return nil
 	}
 
 	if string(conf.ThreadTsOutputVariableName) != "" {
@@ -50,12 +42,12 @@ func exportOutputs(conf *config, resp *http.Response) error {
 
 }
 
-/// Checks if we are requesting an output of anything
+// isRequestingOutput checks if we are requesting an output of anything.
 func isRequestingOutput(conf *config) bool {
 	return string(conf.ThreadTsOutputVariableName) != ""
 }
 
-/// Exports env using envman
+// exportEnvVariable exports env using envman.
 func exportEnvVariable(variable string, value string) error {
 	c := exec.Command("envman", "add", "--key", variable, "--value", value)
 	err := c.Run()
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+)
+
+// requiredScopes returns the OAuth scopes of the bot token needed by the configured features.
+// Reading the history of a private channel requires the groups:history scope instead of channels:history.
+func requiredScopes(c config, private bool) []string {
+	history := "channels:history"
+	if private {
+		history = "groups:history"
+	}
+
+	scopes := map[string]bool{"chat:write": true}
+	if c.Username != "" || c.IconEmoji != "" || c.IconURL != "" {
+		scopes["chat:write.customize"] = true
+	}
+	if isChannelName(strings.TrimSpace(c.Channel)) {
+		scopes["channels:read"] = true
+		scopes["groups:read"] = true
+	}
+	if isEmailList(strings.TrimSpace(c.Channel)) {
+		scopes["users:read.email"] = true
+		scopes["im:write"] = true
+	}
+	if c.EphemeralUser != "" {
+		if isEmail(c.EphemeralUser) {
+			scopes["users:read.email"] = true
+		}
+		scopes["im:write"] = true
+	}
+	if len(c.EscalationRules) > 0 {
+		scopes[history] = true
+	}
+	if c.ApprovalTimeout > 0 {
+		scopes["reactions:read"] = true
+		scopes[history] = true
+	}
+	if c.AutoJoinChannel {
+		scopes["channels:join"] = true
+		scopes["channels:read"] = true
+	}
+
+	var list []string
+	for scope := range scopes {
+		list = append(list, scope)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// grantedScopes parses the comma separated scopes of the X-OAuth-Scopes header.
+func grantedScopes(header string) map[string]bool {
+	granted := map[string]bool{}
+	for _, scope := range strings.Split(header, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			granted[scope] = true
+		}
+	}
+	return granted
+}
+
+// checkMembership checks whether the bot is a member of the target channel
+// and tells whether the channel is private.
+func checkMembership(c config) (bool, error) {
+	channel, err := channelID(c.APIToken, c.Channel)
+	if err != nil {
+		return false, err
+	}
+	if !channelIDPattern.MatchString(channel) || strings.HasPrefix(channel, "D") {
+		return false, nil
+	}
+
+	var info struct {
+		slack.APIResponse
+		Channel struct {
+			IsPrivate bool `json:"is_private"`
+			IsMember  bool `json:"is_member"`
+		} `json:"channel"`
+	}
+	if err := callAPI(c.APIToken, "conversations.info", url.Values{"channel": {channel}}, &info); err != nil {
+		return strings.HasPrefix(channel, "G"), err
+	}
+	private := info.Channel.IsPrivate
+	switch {
+	case info.Channel.IsMember:
+		return private, nil
+	case private:
+		return private, fmt.Errorf("the bot is not a member of the private channel, invite it with /invite")
+	case c.AutoJoinChannel:
+		log.Printf("    the bot is not a member yet, it will join the channel")
+		return private, nil
+	default:
+		return private, fmt.Errorf("the bot is not a member of the channel, invite it with /invite or enable auto_join_channel")
+	}
+}
+
+// preflight verifies the credentials, the token's scopes and the channel membership
+// without sending a message, printing the results as a checklist.
+func preflight(c config) error {
+	log.Infof("Preflight check:")
+
+	failed := 0
+	report := func(name string, err error) {
+		if err != nil {
+			failed++
+			log.Errorf("[✗] %s: %s", name, err)
+			return
+		}
+		log.Donef("[✓] %s", name)
+	}
+
+	for _, cred := range c.Credentials {
+		switch {
+		case cred.IntegrationID != "":
+			_, err := getWebhookURL(c.BuildURL, cred.IntegrationID, string(c.BuildAPIToken))
+			report(fmt.Sprintf("%s resolves to a webhook URL", cred.Name), err)
+		case cred.isWebhook():
+			report(fmt.Sprintf("%s is set", cred.Name), nil)
+		}
+	}
+
+	if c.APIToken == "" {
+		log.Warnf("Incoming webhooks can only be verified by sending a message.")
+		if failed > 0 {
+			return fmt.Errorf("preflight check failed, %d check(s) did not pass", failed)
+		}
+		return nil
+	}
+
+	var identity struct {
+		slack.APIResponse
+		Team string `json:"team"`
+		User string `json:"user"`
+	}
+	header, err := callAPIWithHeader(c.APIToken, "auth.test", nil, &identity)
+	report("API token is valid", err)
+	if err != nil {
+		return fmt.Errorf("preflight check failed")
+	}
+	log.Printf("    workspace: %s, bot user: %s", identity.Team, identity.User)
+
+	// The membership check tells whether the channel is private, which decides the required history scope.
+	var private bool
+	var membershipErr error
+	if c.Channel != "" {
+		private, membershipErr = checkMembership(c)
+	}
+
+	granted := grantedScopes(header.Get("X-OAuth-Scopes"))
+	for _, scope := range requiredScopes(c, private) {
+		var err error
+		if !granted[scope] {
+			err = fmt.Errorf("missing, add it to the Slack app and reinstall it")
+		}
+		report(fmt.Sprintf("Scope %s", scope), err)
+	}
+
+	if c.Channel != "" {
+		report(fmt.Sprintf("Bot can post to %s", c.Channel), membershipErr)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("preflight check failed, %d check(s) did not pass", failed)
+	}
+	return nil
+}
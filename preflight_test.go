@@ -0,0 +1,132 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_requiredScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    config
+		private bool
+		want    []string
+	}{
+		{
+			name: "Channel ID",
+			conf: config{Channel: "C024BE91L"},
+			want: []string{"chat:write"},
+		},
+		{
+			name: "Channel name with custom username",
+			conf: config{Channel: "#general", Username: "Bitrise"},
+			want: []string{"channels:read", "chat:write", "chat:write.customize", "groups:read"},
+		},
+		{
+			name: "Email recipients",
+			conf: config{Channel: "dev@example.com"},
+			want: []string{"chat:write", "im:write", "users:read.email"},
+		},
+		{
+			name: "Ephemeral message to a user ID",
+			conf: config{Channel: "C024BE91L", EphemeralUser: "U024BE7LH"},
+			want: []string{"chat:write", "im:write"},
+		},
+		{
+			name: "Approval in a public channel",
+			conf: config{Channel: "C024BE91L", ApprovalTimeout: time.Minute},
+			want: []string{"channels:history", "chat:write", "reactions:read"},
+		},
+		{
+			name:    "Escalation in a private channel",
+			conf:    config{Channel: "G024BE91L", EscalationRules: []escalationRule{{Threshold: 3}}},
+			private: true,
+			want:    []string{"chat:write", "groups:history"},
+		},
+		{
+			name: "Auto join",
+			conf: config{Channel: "C024BE91L", AutoJoinChannel: true},
+			want: []string{"channels:join", "channels:read", "chat:write"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := requiredScopes(tt.conf, tt.private); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("requiredScopes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_grantedScopes(t *testing.T) {
+	got := grantedScopes("chat:write, channels:read,,groups:history")
+	want := map[string]bool{"chat:write": true, "channels:read": true, "groups:history": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("grantedScopes() = %v, want %v", got, want)
+	}
+	if got := grantedScopes(""); len(got) != 0 {
+		t.Errorf("grantedScopes() = %v, want no scopes", got)
+	}
+}
+
+func Test_checkMembership(t *testing.T) {
+	tests := []struct {
+		name        string
+		channel     string
+		autoJoin    bool
+		info        string
+		wantPrivate bool
+		wantErr     bool
+	}{
+		{
+			name:    "Member",
+			channel: "C024BE91L",
+			info:    `{"ok": true, "channel": {"is_member": true}}`,
+		},
+		{
+			name:        "Member of a private channel",
+			channel:     "G024BE91L",
+			info:        `{"ok": true, "channel": {"is_private": true, "is_member": true}}`,
+			wantPrivate: true,
+		},
+		{
+			name:        "Not a member of a private channel",
+			channel:     "G024BE91L",
+			autoJoin:    true,
+			info:        `{"ok": true, "channel": {"is_private": true}}`,
+			wantPrivate: true,
+			wantErr:     true,
+		},
+		{
+			name:     "Not a member with auto join",
+			channel:  "C024BE91L",
+			autoJoin: true,
+			info:     `{"ok": true, "channel": {}}`,
+		},
+		{
+			name:    "Not a member",
+			channel: "C024BE91L",
+			info:    `{"ok": true, "channel": {}}`,
+			wantErr: true,
+		},
+		{
+			name:    "User ID is not checked",
+			channel: "U024BE7LH",
+			info:    `{"ok": false, "error": "channel_not_found"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newFakeSlack(t, map[string]string{"conversations.info": tt.info})
+
+			private, err := checkMembership(config{APIToken: "token", Channel: tt.channel, AutoJoinChannel: tt.autoJoin})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkMembership() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if private != tt.wantPrivate {
+				t.Errorf("checkMembership() private = %v, want %v", private, tt.wantPrivate)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Presets of the message.
+const (
+	presetNone        = "none"
+	presetBuildReport = "build_report"
+)
+
+// buildReport is the build information shown by the build report preset,
+// read from the standard Bitrise environment variables.
+type buildReport struct {
+	Success          bool
+	AppTitle         string
+	WorkflowID       string
+	Branch           string
+	CommitSubject    string
+	CommitAuthor     string
+	CommitHash       string
+	PullRequest      string
+	RepositoryURL    string
+	BuildNumber      string
+	BuildURL         string
+	TriggerTimestamp string
+}
+
+// blocks returns the Block Kit builder values of the report: a status header,
+// the commit subject, the build fields, the commit author and a button opening the build.
+func (r buildReport) blocks(now time.Time) blockBuilder {
+	emoji, status := ":white_check_mark:", "succeeded"
+	if !r.Success {
+		emoji, status = ":x:", "failed"
+	}
+	subject := "Build"
+	if r.AppTitle != "" {
+		subject = r.AppTitle + " build"
+	}
+	if r.BuildNumber != "" {
+		subject += " #" + r.BuildNumber
+	}
+
+	var fields []string
+	addField := func(title, value string) {
+		if value != "" {
+			fields = append(fields, title+"|"+value)
+		}
+	}
+	addField("Workflow", r.WorkflowID)
+	addField("Branch", r.Branch)
+	if r.PullRequest != "" {
+		pullRequest := "#" + r.PullRequest
+		if u := pullRequestURL(r.RepositoryURL, r.PullRequest); u != "" {
+			pullRequest = fmt.Sprintf("<%s|#%s>", u, r.PullRequest)
+		}
+		addField("Pull request", pullRequest)
+	}
+	if len(r.CommitHash) > 7 {
+		addField("Commit", "`"+r.CommitHash[:7]+"`")
+	} else {
+		addField("Commit", r.CommitHash)
+	}
+	addField("Duration", buildDuration(r.TriggerTimestamp, now))
+
+	var context string
+	if r.CommitAuthor != "" {
+		context = "Committed by " + r.CommitAuthor
+	}
+
+	var buttons string
+	if r.BuildURL != "" {
+		buttons = "View build|" + r.BuildURL
+	}
+
+	return blockBuilder{
+		Header:  fmt.Sprintf("%s %s %s", emoji, subject, status),
+		Text:    r.CommitSubject,
+		Fields:  strings.Join(fields, "\n"),
+		Context: context,
+		Buttons: buttons,
+	}
+}
+
+// pullRequestURL returns the web URL of the pull request on the git hosting of the repository,
+// or an empty string if the repository URL is not recognised.
+func pullRequestURL(repositoryURL, pullRequest string) string {
+	u := strings.TrimSuffix(strings.TrimSpace(repositoryURL), ".git")
+	switch {
+	case strings.HasPrefix(u, "git@"):
+		u = "https://" + strings.Replace(strings.TrimPrefix(u, "git@"), ":", "/", 1)
+	case strings.HasPrefix(u, "ssh://git@"):
+		u = "https://" + strings.TrimPrefix(u, "ssh://git@")
+	case strings.HasPrefix(u, "https://"):
+	default:
+		return ""
+	}
+
+	switch {
+	case strings.Contains(u, "gitlab"):
+		return u + "/-/merge_requests/" + pullRequest
+	case strings.Contains(u, "bitbucket"):
+		return u + "/pull-requests/" + pullRequest
+	default:
+		return u + "/pull/" + pullRequest
+	}
+}
+
+// buildDuration formats the time elapsed since the build was triggered (Unix timestamp),
+// or returns an empty string if the timestamp is not set.
+func buildDuration(triggerTimestamp string, now time.Time) string {
+	seconds, err := strconv.ParseInt(strings.TrimSpace(triggerTimestamp), 10, 64)
+	if err != nil || seconds <= 0 {
+		return ""
+	}
+	elapsed := now.Sub(time.Unix(seconds, 0))
+	if elapsed < 0 {
+		return ""
+	}
+	return elapsed.Truncate(time.Second).String()
+}
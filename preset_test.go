@@ -0,0 +1,82 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_buildReport_blocks(t *testing.T) {
+	now := time.Unix(1700000200, 0)
+	tests := []struct {
+		name   string
+		report buildReport
+		want   blockBuilder
+	}{
+		{
+			name: "Successful build",
+			report: buildReport{
+				Success:          true,
+				AppTitle:         "Demo",
+				WorkflowID:       "primary",
+				Branch:           "main",
+				CommitSubject:    "Fix the login screen",
+				CommitAuthor:     "Jane Doe",
+				CommitHash:       "0123456789abcdef",
+				PullRequest:      "12",
+				RepositoryURL:    "git@github.com:acme/demo.git",
+				BuildNumber:      "42",
+				BuildURL:         "https://app.bitrise.io/build/1",
+				TriggerTimestamp: "1700000000",
+			},
+			want: blockBuilder{
+				Header:  ":white_check_mark: Demo build #42 succeeded",
+				Text:    "Fix the login screen",
+				Fields:  "Workflow|primary\nBranch|main\nPull request|<https://github.com/acme/demo/pull/12|#12>\nCommit|`0123456`\nDuration|3m20s",
+				Context: "Committed by Jane Doe",
+				Buttons: "View build|https://app.bitrise.io/build/1",
+			},
+		},
+		{
+			name:   "Failed build without optional values",
+			report: buildReport{Success: false, BuildNumber: "43"},
+			want:   blockBuilder{Header: ":x: Build #43 failed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.report.blocks(now); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("blocks() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_blockBuilder_override(t *testing.T) {
+	report := blockBuilder{Header: "Header", Text: "Text", Buttons: "View build|https://app.bitrise.io/build/1"}
+	got := report.override(blockBuilder{Header: "Custom header", ImageURL: "https://example.com/a.png"})
+	want := blockBuilder{Header: "Custom header", Text: "Text", ImageURL: "https://example.com/a.png", Buttons: "View build|https://app.bitrise.io/build/1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("override() = %+v, want %+v", got, want)
+	}
+}
+
+func Test_pullRequestURL(t *testing.T) {
+	tests := []struct {
+		repositoryURL string
+		want          string
+	}{
+		{"https://github.com/acme/demo.git", "https://github.com/acme/demo/pull/7"},
+		{"git@gitlab.com:acme/demo.git", "https://gitlab.com/acme/demo/-/merge_requests/7"},
+		{"ssh://git@bitbucket.org/acme/demo.git", "https://bitbucket.org/acme/demo/pull-requests/7"},
+		{"/local/path/demo", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.repositoryURL, func(t *testing.T) {
+			if got := pullRequestURL(tt.repositoryURL, "7"); got != tt.want {
+				t.Errorf("pullRequestURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/bitrise-tools/go-steputils/stepconf"
+)
+
+// redacted replaces the secrets in the logs.
+const redacted = "[REDACTED]"
+
+// secretPatterns match credentials which are not known upfront,
+// like the webhook URLs resolved from the integrations or tokens in response bodies.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`xox[a-z]-[A-Za-z0-9-]+`),
+	regexp.MustCompile(`https://hooks\.slack\.com/[A-Za-z0-9/_-]+`),
+}
+
+// secrets are the known secret values to redact.
+var secrets []string
+
+// registerSecret adds a value to the secrets to redact.
+func registerSecret(s string) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return
+	}
+	for _, secret := range secrets {
+		if secret == s {
+			return
+		}
+	}
+	secrets = append(secrets, s)
+}
+
+// registerSecrets registers the values of the stepconf.Secret fields of a struct.
+func registerSecrets(v interface{}) {
+	value := reflect.ValueOf(v)
+	secretType := reflect.TypeOf(stepconf.Secret(""))
+	for i := 0; i < value.NumField(); i++ {
+		if field := value.Field(i); field.Type() == secretType {
+			registerSecret(field.String())
+		}
+	}
+}
+
+// redact masks the registered secrets and anything looking like a Slack credential.
+func redact(s string) string {
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	for _, pattern := range secretPatterns {
+		s = pattern.ReplaceAllString(s, redacted)
+	}
+	return s
+}
+
+// redactingWriter redacts everything written to the underlying writer.
+type redactingWriter struct {
+	w io.Writer
+}
+
+// Write implements io.Writer.
+func (r redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(r.w, redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
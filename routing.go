@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+// dropChannel is the routing rule channel which drops the message.
+const dropChannel = "drop"
+
+// routingRule overrides where and whether the message is sent
+// if the build finished within Window with the given Status.
+type routingRule struct {
+	Window  timeWindow
+	Status  string
+	Channel string
+	Mention string
+}
+
+// parseRoutingRules parses lines in the form of window|status|channel|mention.
+// Empty lines are omitted, channel and mention are optional.
+func parseRoutingRules(s string) ([]routingRule, error) {
+	var rules []routingRule
+	for _, line := range strings.Split(s, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		cols := strings.Split(line, "|")
+		for len(cols) < 4 {
+			cols = append(cols, "")
+		}
+
+		window, err := parseTimeWindow(cols[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid routing rule: %s", err)
+		}
+		status := strings.ToLower(strings.TrimSpace(cols[1]))
+		switch status {
+		case "":
+			status = "any"
+		case "any", "success", "failure":
+		default:
+			return nil, fmt.Errorf("invalid routing rule status (any, success or failure) in line: %s", line)
+		}
+
+		rules = append(rules, routingRule{
+			Window:  window,
+			Status:  status,
+			Channel: strings.TrimSpace(cols[2]),
+			Mention: strings.TrimSpace(cols[3]),
+		})
+	}
+	return rules, nil
+}
+
+// matches tells whether the rule applies to a build finished at now.
+func (r routingRule) matches(success bool, now time.Time) bool {
+	if (r.Status == "success" && !success) || (r.Status == "failure" && success) {
+		return false
+	}
+	return r.Window.contains(now)
+}
+
+// route applies the first routing rule matching the build status and the time of now
+// in the routing timezone. Returns false if the message should not be sent at all.
+func route(c *config, now time.Time) bool {
+	if len(c.RoutingRules) == 0 {
+		return true
+	}
+
+	now = now.In(c.RoutingLocation)
+	for _, rule := range c.RoutingRules {
+		if !rule.matches(c.Success, now) {
+			continue
+		}
+
+		if rule.Channel == dropChannel {
+			return false
+		}
+		if rule.Channel != "" {
+			log.Infof("Routing the message to %s", rule.Channel)
+			c.Channel = rule.Channel
+		}
+		c.Text = addMention(c.Text, rule.Mention)
+		return true
+	}
+	return true
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_route(t *testing.T) {
+	rules, err := parseRoutingRules("mon-fri 09:00-18:00|any|#team\n*|failure|#oncall|<!here>\n*|success|drop")
+	if err != nil {
+		t.Fatalf("parseRoutingRules() error = %v", err)
+	}
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		success     bool
+		now         time.Time
+		want        bool
+		wantChannel string
+		wantText    string
+	}{
+		{
+			name:        "Business hours",
+			success:     true,
+			now:         time.Date(2024, 3, 5, 10, 0, 0, 0, berlin),
+			want:        true,
+			wantChannel: "#team",
+			wantText:    "Build finished",
+		},
+		{
+			name:        "Failure outside of business hours",
+			now:         time.Date(2024, 3, 5, 17, 30, 0, 0, time.UTC),
+			want:        true,
+			wantChannel: "#oncall",
+			wantText:    "<!here> Build finished",
+		},
+		{
+			name:        "Success outside of business hours",
+			success:     true,
+			now:         time.Date(2024, 3, 9, 10, 0, 0, 0, berlin),
+			want:        false,
+			wantChannel: "#general",
+			wantText:    "Build finished",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := config{Success: tt.success, Channel: "#general", Text: "Build finished", RoutingRules: rules, RoutingLocation: berlin}
+			if got := route(&c, tt.now); got != tt.want {
+				t.Errorf("route() = %v, want %v", got, tt.want)
+			}
+			if c.Channel != tt.wantChannel || c.Text != tt.wantText {
+				t.Errorf("route() channel = %s, text = %s, want %s, %s", c.Channel, c.Text, tt.wantChannel, tt.wantText)
+			}
+		})
+	}
+}
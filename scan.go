@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+)
+
+// Secret scanning modes.
+const (
+	secretScanningOff    = "off"
+	secretScanningRedact = "redact"
+	secretScanningBlock  = "block"
+)
+
+// secretKind is a pattern of a well known secret.
+type secretKind struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// secretKinds are the well known secrets the outgoing content is scanned for.
+var secretKinds = []secretKind{
+	{"private key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----(?s:.*?)(-----END [A-Z ]*PRIVATE KEY-----|$)`)},
+	{"AWS access key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{"GitHub token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})`)},
+}
+
+// highEntropyCandidate matches the words checked for high entropy.
+var highEntropyCandidate = regexp.MustCompile(`[A-Za-z0-9+/=_-]{32,}`)
+
+// entropy returns the Shannon entropy of s in bits per character.
+func entropy(s string) float64 {
+	counts := map[rune]int{}
+	for _, r := range s {
+		counts[r]++
+	}
+	var h float64
+	n := float64(len(s))
+	for _, c := range counts {
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// isHighEntropy tells whether s looks like a random generated secret:
+// it has a high entropy and mixes lower case letters, upper case letters and digits.
+func isHighEntropy(s string) bool {
+	var lower, upper, digit bool
+	for _, r := range s {
+		lower = lower || unicode.IsLower(r)
+		upper = upper || unicode.IsUpper(r)
+		digit = digit || unicode.IsDigit(r)
+	}
+	return lower && upper && digit && entropy(s) > 4.2
+}
+
+// scanText redacts the possible secrets in s and returns the names of the found kinds.
+func scanText(s string) (string, []string) {
+	var found []string
+	for _, kind := range secretKinds {
+		if kind.Pattern.MatchString(s) {
+			found = append(found, kind.Name)
+			s = kind.Pattern.ReplaceAllString(s, redacted)
+		}
+	}
+
+	highEntropy := false
+	s = highEntropyCandidate.ReplaceAllStringFunc(s, func(word string) string {
+		if !isHighEntropy(word) {
+			return word
+		}
+		highEntropy = true
+		return redacted
+	})
+	if highEntropy {
+		found = append(found, "high entropy string")
+	}
+	return s, found
+}
+
+// scanMessage scans the text, blocks, attachments and fields of the message for possible secrets.
+// In redact mode the secrets are replaced in the message, in block mode an error is returned.
+func scanMessage(msg *slack.Message, mode string) error {
+	if mode == "" || mode == secretScanningOff {
+		return nil
+	}
+
+	kinds := map[string]bool{}
+	scan := func(location string, s *string) {
+		redactedText, found := scanText(*s)
+		for _, kind := range found {
+			log.Warnf("Possible %s found in the %s", kind, location)
+			kinds[kind] = true
+		}
+		*s = redactedText
+	}
+
+	scan("text", &msg.Text)
+	scan("blocks", &msg.Blocks)
+	for i := range msg.Attachments {
+		a := &msg.Attachments[i]
+		scan("attachment fallback", &a.Fallback)
+		scan("attachment pretext", &a.PreText)
+		scan("attachment author name", &a.AuthorName)
+		scan("attachment title", &a.Title)
+		scan("attachment text", &a.Text)
+		scan("attachment footer", &a.Footer)
+		if len(a.Blocks) > 0 {
+			blocks := string(a.Blocks)
+			scan("attachment blocks", &blocks)
+			a.Blocks = json.RawMessage(blocks)
+		}
+		for j := range a.Fields {
+			scan("field title", &a.Fields[j].Title)
+			scan("field value", &a.Fields[j].Value)
+		}
+		for j := range a.Buttons {
+			scan("button text", &a.Buttons[j].Text)
+		}
+	}
+
+	if len(kinds) == 0 || mode == secretScanningRedact {
+		return nil
+	}
+
+	var names []string
+	for kind := range kinds {
+		names = append(names, kind)
+	}
+	sort.Strings(names)
+	return &classifiedError{Class: errorClassRejected, Err: fmt.Errorf("the message contains possible secrets (%s), not sending it", strings.Join(names, ", "))}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+// scheduledMessageIDOutputKey is the output exporting the ID of the scheduled message.
+const scheduledMessageIDOutputKey = "SLACK_SCHEDULED_MESSAGE_ID"
+
+// deliveryWindow is the time range messages are allowed to be delivered in.
+type deliveryWindow struct {
+	Window   timeWindow
+	Location *time.Location
+}
+
+// parseDeliveryWindow parses a window in the form of [next] [days] [HH:MM[-HH:MM]] [timezone],
+// eg. next weekday 09:00 Europe/Berlin. The timezone defaults to UTC.
+func parseDeliveryWindow(s string) (deliveryWindow, error) {
+	fields := strings.Fields(s)
+	if len(fields) > 0 && strings.EqualFold(fields[0], "next") {
+		fields = fields[1:]
+	}
+
+	if len(fields) == 0 {
+		return deliveryWindow{}, fmt.Errorf("invalid delivery window: %s", s)
+	}
+
+	location := time.UTC
+	if last := fields[len(fields)-1]; strings.Contains(last, "/") || last == "UTC" {
+		var err error
+		if location, err = time.LoadLocation(last); err != nil {
+			return deliveryWindow{}, fmt.Errorf("invalid delivery window timezone: %s", err)
+		}
+		fields = fields[:len(fields)-1]
+	}
+
+	window, err := parseTimeWindow(strings.Join(fields, " "))
+	if err != nil {
+		return deliveryWindow{}, err
+	}
+	return deliveryWindow{Window: window, Location: location}, nil
+}
+
+// postAt returns when a message finished at now should be delivered,
+// or a zero time if it can be delivered right away.
+func (d deliveryWindow) postAt(now time.Time) time.Time {
+	now = now.In(d.Location)
+	if d.Window.contains(now) {
+		return time.Time{}
+	}
+	return d.Window.nextStart(now)
+}
+
+// deleteScheduledMessage cancels a message scheduled by an earlier run of the step.
+func deleteScheduledMessage(conf config) error {
+	channel, err := channelID(conf.APIToken, conf.Channel)
+	if err != nil {
+		return fmt.Errorf("failed to resolve channel %s: %w", conf.Channel, err)
+	}
+
+	return newBotClient(conf.APIToken).DeleteScheduled(context.Background(), channel, conf.CancelScheduledMessageID)
+}
+
+// schedule sets the delivery time of the message according to the delivery window.
+func schedule(c *config, now time.Time) {
+	if c.DeliveryWindow == nil {
+		return
+	}
+	postAt := c.DeliveryWindow.postAt(now)
+	if postAt.IsZero() {
+		log.Infof("Within the delivery window, sending the message right away")
+		return
+	}
+	log.Infof("Outside of the delivery window, scheduling the message to %s", postAt.Format(time.RFC1123))
+	c.PostAt = postAt.Unix()
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_deliveryWindow_postAt(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		window string
+		now    time.Time
+		want   time.Time
+	}{
+		{
+			name:   "Nightly build is scheduled to the morning",
+			window: "next weekday 09:00 Europe/Berlin",
+			now:    time.Date(2024, 3, 5, 3, 0, 0, 0, berlin),
+			want:   time.Date(2024, 3, 5, 9, 0, 0, 0, berlin),
+		},
+		{
+			name:   "Friday evening build is scheduled to Monday",
+			window: "mon-fri 09:00-18:00 Europe/Berlin",
+			now:    time.Date(2024, 3, 8, 20, 0, 0, 0, berlin),
+			want:   time.Date(2024, 3, 11, 9, 0, 0, 0, berlin),
+		},
+		{
+			name:   "Build within the window is sent right away",
+			window: "weekday 09:00-18:00 Europe/Berlin",
+			now:    time.Date(2024, 3, 5, 14, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := parseDeliveryWindow(tt.window)
+			if err != nil {
+				t.Fatalf("parseDeliveryWindow() error = %v", err)
+			}
+			if got := d.postAt(tt.now); !got.Equal(tt.want) {
+				t.Errorf("postAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
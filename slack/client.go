@@ -0,0 +1,345 @@
+// Package slack sends messages to Slack through incoming webhooks, bot tokens
+// or the Slack integrations of Bitrise workspaces.
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-utils/retry"
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+// DefaultAPIURL is the base URL of the Slack Web API.
+const DefaultAPIURL = "https://slack.com/api"
+
+// ErrWebAPIRequired is returned by the methods which are not supported by incoming webhooks.
+var ErrWebAPIRequired = errors.New("the method requires a bot token, incoming webhooks do not support it")
+
+// APIResponse is the envelope shared by every Slack Web API response.
+type APIResponse struct {
+	OK               bool   `json:"ok"`
+	Error            string `json:"error,omitempty"`
+	ResponseMetadata struct {
+		NextCursor string `json:"next_cursor"`
+	} `json:"response_metadata"`
+}
+
+// Response is the response of posting a message.
+// Incoming webhooks do not return any useful response information, only OK is set for them.
+type Response struct {
+	// OK tells whether the request was successful.
+	OK bool `json:"ok"`
+
+	// Error is the error code if the request was not successful.
+	Error string `json:"error"`
+
+	// Channel is the ID of the channel the message was sent to.
+	Channel string `json:"channel"`
+
+	// Timestamp is the ts of the message, used to reply in its thread or to update it.
+	Timestamp string `json:"ts"`
+
+	// ScheduledMessageID is the ID of the scheduled message.
+	ScheduledMessageID string `json:"scheduled_message_id"`
+}
+
+// APIError is returned when Slack answers a Web API call with ok=false.
+type APIError struct {
+	// Method is the called Web API method (eg. chat.postMessage).
+	Method string
+
+	// Code is the machine readable error returned by Slack (eg. channel_not_found).
+	Code string
+}
+
+// Error implements builtin errors.Error.
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s failed: %s", e.Method, e.Code)
+}
+
+// StatusError is returned when the server answers with an unexpected HTTP status code.
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+// Error implements builtin errors.Error.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("server error: %s, response: %s", e.Status, e.Body)
+}
+
+// RequestError is returned when the request could not be sent or its response could not be read.
+type RequestError struct {
+	Err error
+}
+
+// Error implements builtin errors.Error.
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("failed to send the request: %s", e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// Integration is the Slack integration of a Bitrise workspace,
+// resolved to an incoming webhook URL through the build's API.
+type Integration struct {
+	// BuildURL is the URL of the running build (BITRISE_BUILD_URL).
+	BuildURL string
+
+	// ID identifies the integration in the workspace.
+	ID string
+
+	// BuildAPIToken authorizes the request (BITRISE_BUILD_API_TOKEN).
+	BuildAPIToken string
+}
+
+// Client sends messages with one of the transports: bot token, incoming webhook or workspace integration.
+type Client struct {
+	httpClient  *http.Client
+	apiURL      string
+	token       string
+	webhookURL  string
+	integration *Integration
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client of the requests, http.DefaultClient by default.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithAPIURL sets the base URL of the Slack Web API, DefaultAPIURL by default.
+func WithAPIURL(apiURL string) Option {
+	return func(c *Client) {
+		c.apiURL = apiURL
+	}
+}
+
+func newClient(opts []Option) *Client {
+	c := &Client{httpClient: http.DefaultClient, apiURL: DefaultAPIURL}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// NewBotClient returns a client calling the Web API with a bot token.
+func NewBotClient(token string, opts ...Option) *Client {
+	c := newClient(opts)
+	c.token = token
+	return c
+}
+
+// NewWebhookClient returns a client posting to an incoming webhook.
+func NewWebhookClient(webhookURL string, opts ...Option) *Client {
+	c := newClient(opts)
+	c.webhookURL = strings.TrimSpace(webhookURL)
+	return c
+}
+
+// NewIntegrationClient returns a client posting to the incoming webhook of a workspace integration.
+func NewIntegrationClient(integration Integration, opts ...Option) *Client {
+	c := newClient(opts)
+	c.integration = &integration
+	return c
+}
+
+// IsWebhook tells whether the client posts to an incoming webhook.
+func (c *Client) IsWebhook() bool {
+	return c.token == ""
+}
+
+// WebhookURL returns the incoming webhook URL of the client, resolving it once for integrations.
+func (c *Client) WebhookURL(ctx context.Context) (string, error) {
+	if c.webhookURL != "" || c.integration == nil {
+		return c.webhookURL, nil
+	}
+
+	req, err := retryablehttp.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/integrations/slack/%s", c.integration.BuildURL, c.integration.ID), http.NoBody)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Add("Build-Api-Token", c.integration.BuildAPIToken)
+	client := retry.NewHTTPClient()
+	client.HTTPClient = c.httpClient
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", &RequestError{Err: err}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", &RequestError{Err: err}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", &StatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(body)}
+	}
+
+	var webhookData struct {
+		WebhookURL string `json:"webhook_url"`
+	}
+	if err := json.Unmarshal(body, &webhookData); err != nil {
+		return "", fmt.Errorf("failed to parse response: %s", err)
+	}
+	c.webhookURL = webhookData.WebhookURL
+	return c.webhookURL, nil
+}
+
+// Post sends the message. With a bot token it is scheduled if PostAt is set
+// and sent as an ephemeral message if User is set.
+func (c *Client) Post(ctx context.Context, msg Message) (Response, error) {
+	if c.IsWebhook() {
+		return c.postWebhook(ctx, msg)
+	}
+
+	method := "chat.postMessage"
+	if msg.PostAt != 0 {
+		method = "chat.scheduleMessage"
+	} else if msg.User != "" {
+		method = "chat.postEphemeral"
+	}
+	return c.postJSON(ctx, method, msg)
+}
+
+// Update replaces the message identified by its Channel and Ts.
+func (c *Client) Update(ctx context.Context, msg Message) (Response, error) {
+	if c.IsWebhook() {
+		return Response{}, ErrWebAPIRequired
+	}
+	return c.postJSON(ctx, "chat.update", msg)
+}
+
+// Delete deletes the message with the given timestamp.
+func (c *Client) Delete(ctx context.Context, channel, ts string) error {
+	if c.IsWebhook() {
+		return ErrWebAPIRequired
+	}
+	_, err := c.Call(ctx, "chat.delete", url.Values{"channel": {channel}, "ts": {ts}}, nil)
+	return err
+}
+
+// DeleteScheduled cancels the scheduled message with the given ID.
+func (c *Client) DeleteScheduled(ctx context.Context, channel, scheduledMessageID string) error {
+	if c.IsWebhook() {
+		return ErrWebAPIRequired
+	}
+	_, err := c.Call(ctx, "chat.deleteScheduledMessage", url.Values{"channel": {channel}, "scheduled_message_id": {scheduledMessageID}}, nil)
+	return err
+}
+
+// Call calls a Web API method with form encoded params, decodes the JSON response into v
+// and returns the response headers.
+func (c *Client) Call(ctx context.Context, method string, params url.Values, v interface{}) (http.Header, error) {
+	if c.IsWebhook() {
+		return nil, ErrWebAPIRequired
+	}
+	header, body, err := c.do(ctx, c.apiURL+"/"+method, "application/x-www-form-urlencoded", []byte(params.Encode()))
+	if err != nil {
+		return header, err
+	}
+	log.Debugf("Response from Slack (%s): %s\n", method, body)
+
+	var envelope APIResponse
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return header, fmt.Errorf("failed to parse response: %s", err)
+	}
+	if !envelope.OK {
+		return header, &APIError{Method: method, Code: envelope.Error}
+	}
+
+	if v == nil {
+		return header, nil
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return header, fmt.Errorf("failed to parse response: %s", err)
+	}
+	return header, nil
+}
+
+// postJSON posts the message to a Web API method.
+func (c *Client) postJSON(ctx context.Context, method string, msg Message) (Response, error) {
+	var response Response
+
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return response, err
+	}
+	log.Debugf("Request to Slack (%s): %s\n", method, b)
+
+	_, body, err := c.do(ctx, c.apiURL+"/"+method, "application/json; charset=utf-8", b)
+	if err != nil {
+		return response, err
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return response, fmt.Errorf("failed to parse response: %s", err)
+	}
+	if !response.OK {
+		return response, &APIError{Method: method, Code: response.Error}
+	}
+	return response, nil
+}
+
+// postWebhook posts the message to the incoming webhook.
+func (c *Client) postWebhook(ctx context.Context, msg Message) (Response, error) {
+	webhookURL, err := c.WebhookURL(ctx)
+	if err != nil {
+		return Response{}, err
+	}
+
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return Response{}, err
+	}
+	log.Debugf("Request to Slack: %s\n", b)
+
+	if _, _, err := c.do(ctx, webhookURL, "application/json; charset=utf-8", b); err != nil {
+		return Response{}, err
+	}
+	return Response{OK: true}, nil
+}
+
+// do sends a POST request and returns the response headers and body.
+func (c *Client) do(ctx context.Context, url, contentType string, body []byte) (http.Header, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Add("Content-Type", contentType)
+	if c.token != "" {
+		req.Header.Add("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, &RequestError{Err: err}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.Header, nil, &RequestError{Err: fmt.Errorf("server error: %s, failed to read response: %s", resp.Status, err)}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp.Header, respBody, &StatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(respBody)}
+	}
+	return resp.Header, respBody, nil
+}
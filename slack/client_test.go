@@ -0,0 +1,137 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// request is a request received by the fake server.
+type request struct {
+	Path          string
+	Authorization string
+	Body          string
+}
+
+// newFakeServer starts a server answering every request with the given status and body,
+// recording the received requests.
+func newFakeServer(t *testing.T, status int, body string) (*httptest.Server, *[]request) {
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		requests = append(requests, request{Path: r.URL.Path, Authorization: r.Header.Get("Authorization"), Body: string(b)})
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestClient_Post(t *testing.T) {
+	tests := []struct {
+		name       string
+		msg        Message
+		status     int
+		body       string
+		webhook    bool
+		want       Response
+		wantPath   string
+		wantAPIErr *APIError
+	}{
+		{
+			name:     "Post message",
+			msg:      Message{Channel: "C024BE91L", Text: "hello"},
+			status:   http.StatusOK,
+			body:     `{"ok": true, "channel": "C024BE91L", "ts": "1405894322.002768"}`,
+			want:     Response{OK: true, Channel: "C024BE91L", Timestamp: "1405894322.002768"},
+			wantPath: "/chat.postMessage",
+		},
+		{
+			name:     "Schedule message",
+			msg:      Message{Channel: "C024BE91L", Text: "hello", PostAt: 1405894322},
+			status:   http.StatusOK,
+			body:     `{"ok": true, "channel": "C024BE91L", "scheduled_message_id": "Q1298393284"}`,
+			want:     Response{OK: true, Channel: "C024BE91L", ScheduledMessageID: "Q1298393284"},
+			wantPath: "/chat.scheduleMessage",
+		},
+		{
+			name:       "API error",
+			msg:        Message{Channel: "#unknown", Text: "hello"},
+			status:     http.StatusOK,
+			body:       `{"ok": false, "error": "channel_not_found"}`,
+			want:       Response{Error: "channel_not_found"},
+			wantPath:   "/chat.postMessage",
+			wantAPIErr: &APIError{Method: "chat.postMessage", Code: "channel_not_found"},
+		},
+		{
+			name:     "Webhook",
+			msg:      Message{Text: "hello"},
+			status:   http.StatusOK,
+			body:     `ok`,
+			webhook:  true,
+			want:     Response{OK: true},
+			wantPath: "/services/T000/B000/XXXX",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newFakeServer(t, tt.status, tt.body)
+			client := NewBotClient("xoxb-token", WithAPIURL(server.URL))
+			if tt.webhook {
+				client = NewWebhookClient(server.URL + "/services/T000/B000/XXXX")
+			}
+
+			got, err := client.Post(context.Background(), tt.msg)
+			if tt.wantAPIErr != nil {
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) || *apiErr != *tt.wantAPIErr {
+					t.Errorf("Post() error = %v, want %v", err, tt.wantAPIErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Post() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Post() = %v, want %v", got, tt.want)
+			}
+			if len(*requests) != 1 || (*requests)[0].Path != tt.wantPath {
+				t.Errorf("Post() requests = %v, want one to %s", *requests, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestClient_WebhookURL(t *testing.T) {
+	server, _ := newFakeServer(t, http.StatusOK, `{"webhook_url": "https://hooks.slack.com/services/T000/B000/XXXX"}`)
+	client := NewIntegrationClient(Integration{BuildURL: server.URL, ID: "integration", BuildAPIToken: "token"})
+
+	got, err := client.WebhookURL(context.Background())
+	if err != nil {
+		t.Fatalf("WebhookURL() error = %v", err)
+	}
+	if want := "https://hooks.slack.com/services/T000/B000/XXXX"; got != want {
+		t.Errorf("WebhookURL() = %v, want %v", got, want)
+	}
+
+	if _, err := client.Update(context.Background(), Message{Ts: "1405894322.002768"}); err != ErrWebAPIRequired {
+		t.Errorf("Update() error = %v, want %v", err, ErrWebAPIRequired)
+	}
+}
+
+func TestClient_Call_statusError(t *testing.T) {
+	server, requests := newFakeServer(t, http.StatusTooManyRequests, `rate limited`)
+	client := NewBotClient("xoxb-token", WithAPIURL(server.URL))
+
+	_, err := client.Call(context.Background(), "auth.test", nil, nil)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("Call() error = %v, want status error 429", err)
+	}
+	if len(*requests) != 1 || (*requests)[0].Authorization != "Bearer xoxb-token" {
+		t.Errorf("Call() requests = %v, want one with the token", *requests)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+	"github.com/bitrise-tools/go-steputils/stepconf"
+)
+
+// slackAPIURL is the base URL of the Slack Web API, overridden in tests.
+var slackAPIURL = slack.DefaultAPIURL
+
+// newSlackClient returns a client delivering with the API token,
+// or with the webhook URL of the config if set.
+func newSlackClient(conf config) *slack.Client {
+	if conf.WebhookURL != "" {
+		return slack.NewWebhookClient(conf.WebhookURL, slack.WithHTTPClient(httpClient))
+	}
+	return newBotClient(conf.APIToken)
+}
+
+// newBotClient returns a client calling the Web API with the token.
+func newBotClient(token stepconf.Secret) *slack.Client {
+	return slack.NewBotClient(string(token), slack.WithHTTPClient(httpClient), slack.WithAPIURL(slackAPIURL))
+}
+
+// callAPI calls a Slack Web API method with form encoded params
+// and decodes the JSON response into v.
+func callAPI(token stepconf.Secret, method string, params url.Values, v interface{}) error {
+	_, err := callAPIWithHeader(token, method, params, v)
+	return err
+}
+
+// callAPIWithHeader is like callAPI, but also returns the response headers.
+func callAPIWithHeader(token stepconf.Secret, method string, params url.Values, v interface{}) (http.Header, error) {
+	return newBotClient(token).Call(context.Background(), method, params, v)
+}
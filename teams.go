@@ -0,0 +1,151 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+)
+
+// adaptiveCardContentType is the content type of Adaptive Card attachments.
+const adaptiveCardContentType = "application/vnd.microsoft.card.adaptive"
+
+// teamsMessage is the payload of a Microsoft Teams incoming webhook.
+// See also: https://learn.microsoft.com/en-us/microsoftteams/platform/webhooks-and-connectors/how-to/connectors-using
+type teamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+// teamsAttachment wraps an Adaptive Card.
+type teamsAttachment struct {
+	ContentType string       `json:"contentType"`
+	Content     adaptiveCard `json:"content"`
+}
+
+// adaptiveCard is a card of Adaptive Card elements.
+// See also: https://adaptivecards.io/explorer/AdaptiveCard.html
+type adaptiveCard struct {
+	Schema  string                   `json:"$schema"`
+	Type    string                   `json:"type"`
+	Version string                   `json:"version"`
+	Body    []map[string]interface{} `json:"body"`
+	Actions []map[string]interface{} `json:"actions,omitempty"`
+	MSTeams map[string]string        `json:"msteams"`
+}
+
+// teamsNotifier delivers the message to a Microsoft Teams incoming webhook as an Adaptive Card.
+type teamsNotifier struct {
+	webhookURL string
+}
+
+// Notify implements Notifier.
+func (n teamsNotifier) Notify(msg slack.Message) (slack.Response, error) {
+	if _, _, err := postJSON(n.webhookURL, newTeamsMessage(msg)); err != nil {
+		return slack.Response{}, err
+	}
+	return slack.Response{OK: true}, nil
+}
+
+// textBlock returns a TextBlock element with the given properties.
+func textBlock(text string, properties map[string]interface{}) map[string]interface{} {
+	block := map[string]interface{}{"type": "TextBlock", "text": toMarkdown(text), "wrap": true}
+	for k, v := range properties {
+		block[k] = v
+	}
+	return block
+}
+
+// newTeamsMessage converts the title, texts, colour, fields, image and buttons of the message to an Adaptive Card.
+func newTeamsMessage(msg slack.Message) teamsMessage {
+	var items []map[string]interface{}
+	if msg.Text != "" {
+		items = append(items, textBlock(msg.Text, nil))
+	}
+
+	var actions []map[string]interface{}
+	style := "default"
+	for _, a := range msg.Attachments {
+		style = containerStyle(a.Color)
+		if a.PreText != "" {
+			items = append(items, textBlock(a.PreText, map[string]interface{}{"isSubtle": true}))
+		}
+		if a.AuthorName != "" {
+			items = append(items, textBlock(a.AuthorName, map[string]interface{}{"size": "small", "weight": "bolder"}))
+		}
+		if a.Title != "" {
+			title := a.Title
+			if a.TitleLink != "" {
+				title = "[" + title + "](" + a.TitleLink + ")"
+			}
+			items = append(items, textBlock(title, map[string]interface{}{"size": "medium", "weight": "bolder"}))
+		}
+		if a.Text != "" {
+			items = append(items, textBlock(a.Text, nil))
+		}
+		if len(a.Fields) > 0 {
+			var facts []map[string]string
+			for _, f := range a.Fields {
+				facts = append(facts, map[string]string{"title": f.Title, "value": toMarkdown(f.Value)})
+			}
+			items = append(items, map[string]interface{}{"type": "FactSet", "facts": facts})
+		}
+		if a.ImageURL != "" {
+			items = append(items, map[string]interface{}{"type": "Image", "url": a.ImageURL})
+		}
+		if a.Footer != "" {
+			items = append(items, textBlock(a.Footer, map[string]interface{}{"size": "small", "isSubtle": true}))
+		}
+		for _, b := range a.Buttons {
+			actions = append(actions, map[string]interface{}{"type": "Action.OpenUrl", "title": b.Text, "url": b.URL})
+		}
+	}
+
+	return teamsMessage{
+		Type: "message",
+		Attachments: []teamsAttachment{{
+			ContentType: adaptiveCardContentType,
+			Content: adaptiveCard{
+				Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+				Type:    "AdaptiveCard",
+				Version: "1.4",
+				Body: []map[string]interface{}{{
+					"type":  "Container",
+					"style": style,
+					"bleed": true,
+					"items": items,
+				}},
+				Actions: actions,
+				MSTeams: map[string]string{"width": "Full"},
+			},
+		}},
+	}
+}
+
+// containerStyle maps the colour of a Slack attachment to the closest Adaptive Card container style.
+func containerStyle(color string) string {
+	switch color {
+	case "good":
+		return "good"
+	case "warning":
+		return "warning"
+	case "danger":
+		return "attention"
+	}
+
+	hex := strings.TrimPrefix(color, "#")
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return "default"
+	}
+	r, g, b := rgb>>16, rgb>>8&0xff, rgb&0xff
+	switch {
+	case g > r && g >= b:
+		return "good"
+	case r > b && g > b && g*10 >= r*7:
+		return "warning"
+	case r > g && r > b:
+		return "attention"
+	}
+	return "accent"
+}
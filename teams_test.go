@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+)
+
+func Test_newTeamsMessage(t *testing.T) {
+	msg := slack.Message{
+		Text: "Build *#42* finished",
+		Attachments: []slack.Attachment{{
+			Color:     "#3bc3a3",
+			Title:     "Success",
+			TitleLink: "https://app.bitrise.io/build/1",
+			Text:      "See <https://app.bitrise.io/build/1|the logs>",
+			Fields:    []slack.Field{{Title: "App", Value: "Demo"}},
+			Buttons:   []slack.Button{{Text: "Open", URL: "https://app.bitrise.io"}},
+		}},
+	}
+
+	b, err := json.Marshal(newTeamsMessage(msg))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	want := `{"type":"message","attachments":[{"contentType":"application/vnd.microsoft.card.adaptive","content":{` +
+		`"$schema":"http://adaptivecards.io/schemas/adaptive-card.json","type":"AdaptiveCard","version":"1.4",` +
+		`"body":[{"bleed":true,"items":[` +
+		`{"text":"Build **#42** finished","type":"TextBlock","wrap":true},` +
+		`{"size":"medium","text":"[Success](https://app.bitrise.io/build/1)","type":"TextBlock","weight":"bolder","wrap":true},` +
+		`{"text":"See [the logs](https://app.bitrise.io/build/1)","type":"TextBlock","wrap":true},` +
+		`{"facts":[{"title":"App","value":"Demo"}],"type":"FactSet"}` +
+		`],"style":"good","type":"Container"}],` +
+		`"actions":[{"title":"Open","type":"Action.OpenUrl","url":"https://app.bitrise.io"}],` +
+		`"msteams":{"width":"Full"}}}]}`
+	if got := string(b); got != want {
+		t.Errorf("newTeamsMessage() = %v, want %v", got, want)
+	}
+}
+
+func Test_containerStyle(t *testing.T) {
+	tests := []struct {
+		color string
+		want  string
+	}{
+		{color: "good", want: "good"},
+		{color: "danger", want: "attention"},
+		{color: "#3bc3a3", want: "good"},
+		{color: "#f0741f", want: "attention"},
+		{color: "#f2c744", want: "warning"},
+		{color: "#439FE0", want: "accent"},
+		{color: "blue", want: "default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.color, func(t *testing.T) {
+			if got := containerStyle(tt.color); got != tt.want {
+				t.Errorf("containerStyle() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
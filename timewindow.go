@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// minutesPerDay is the number of minutes in a day without DST changes.
+const minutesPerDay = 24 * 60
+
+// dayNames maps the accepted day names to weekdays.
+var dayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// timeWindow is a recurring weekly time range, eg. mon-fri 09:00-18:00.
+type timeWindow struct {
+	// Days are the days of the week the window starts on, indexed by time.Weekday.
+	Days [7]bool
+
+	// Start and End are minutes since midnight, End is exclusive.
+	// An End before Start means the window lasts until the next day.
+	Start, End int
+
+	// HasEnd is false if only the start time was given.
+	HasEnd bool
+}
+
+// parseDays parses a day specification, eg. weekday, weekend, mon-fri, sat,sun or * for every day.
+func parseDays(s string) ([7]bool, bool) {
+	var days [7]bool
+	switch strings.ToLower(s) {
+	case "*", "day", "daily":
+		return [7]bool{true, true, true, true, true, true, true}, true
+	case "weekday", "weekdays":
+		return [7]bool{false, true, true, true, true, true, false}, true
+	case "weekend", "weekends":
+		return [7]bool{true, false, false, false, false, false, true}, true
+	}
+
+	for _, part := range strings.Split(strings.ToLower(s), ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		first, ok := dayNames[bounds[0]]
+		if !ok {
+			return days, false
+		}
+		last := first
+		if len(bounds) == 2 {
+			if last, ok = dayNames[bounds[1]]; !ok {
+				return days, false
+			}
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return days, true
+}
+
+// parseClock parses a HH:MM time of the day into minutes since midnight.
+func parseClock(s string) (int, bool) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// parseTimeWindow parses a window in the form of [days] [HH:MM[-HH:MM]].
+// Days default to every day and the time range defaults to the whole day.
+func parseTimeWindow(s string) (timeWindow, error) {
+	w := timeWindow{Start: 0, End: minutesPerDay, HasEnd: true}
+	w.Days, _ = parseDays("*")
+
+	fields := strings.Fields(s)
+	if len(fields) > 2 {
+		return w, fmt.Errorf("invalid time window: %s", s)
+	}
+	for i, field := range fields {
+		if days, ok := parseDays(field); ok && i == 0 {
+			w.Days = days
+			continue
+		}
+
+		bounds := strings.SplitN(field, "-", 2)
+		start, ok := parseClock(bounds[0])
+		if !ok {
+			return w, fmt.Errorf("invalid time window: %s", s)
+		}
+		w.Start, w.HasEnd = start, len(bounds) == 2
+		if w.HasEnd {
+			if w.End, ok = parseClock(bounds[1]); !ok {
+				return w, fmt.Errorf("invalid time window: %s", s)
+			}
+		}
+	}
+	return w, nil
+}
+
+// contains tells whether t falls into the window.
+func (w timeWindow) contains(t time.Time) bool {
+	if !w.HasEnd {
+		return false
+	}
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	if w.Start <= w.End {
+		return w.Days[day] && w.Start <= minute && minute < w.End
+	}
+	previous := (day + 6) % 7
+	return (w.Days[day] && minute >= w.Start) || (w.Days[previous] && minute < w.End)
+}
+
+// nextStart returns the first start of the window after t.
+func (w timeWindow) nextStart(t time.Time) time.Time {
+	for d := 0; d <= 7; d++ {
+		start := time.Date(t.Year(), t.Month(), t.Day()+d, w.Start/60, w.Start%60, 0, 0, t.Location())
+		if w.Days[start.Weekday()] && start.After(t) {
+			return start
+		}
+	}
+	return t
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+	"github.com/bitrise-tools/go-steputils/stepconf"
+)
+
+// isEmail tells whether s looks like an email address rather than a Slack ID or name.
+func isEmail(s string) bool {
+	at := strings.Index(s, "@")
+	return at > 0 && strings.Contains(s[at:], ".")
+}
+
+// userIDsByEmail caches the users looked up within the run.
+var userIDsByEmail = map[string]string{}
+
+// lookupUserByEmail returns the ID of the active Slack user with the given email address.
+func lookupUserByEmail(token stepconf.Secret, email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if id, ok := userIDsByEmail[email]; ok {
+		return id, nil
+	}
+
+	var response struct {
+		slack.APIResponse
+		User struct {
+			ID      string `json:"id"`
+			Deleted bool   `json:"deleted"`
+		} `json:"user"`
+	}
+	if err := callAPI(token, "users.lookupByEmail", url.Values{"email": {email}}, &response); err != nil {
+		var apiErr *slack.APIError
+		if errors.As(err, &apiErr) && apiErr.Code == "users_not_found" {
+			return "", configurationErrorf("no Slack user found with the email address %s", email)
+		}
+		return "", err
+	}
+	if response.User.Deleted {
+		return "", configurationErrorf("the Slack user with the email address %s (%s) is deactivated", email, response.User.ID)
+	}
+
+	userIDsByEmail[email] = response.User.ID
+	return response.User.ID, nil
+}
+
+// resolveUser returns the Slack ID of a user given by ID or email address.
+func resolveUser(token stepconf.Secret, user string) (string, error) {
+	if !isEmail(user) {
+		return user, nil
+	}
+	return lookupUserByEmail(token, user)
+}
+
+// openDM opens a direct message conversation with the given users and returns its channel ID.
+// Multiple users, separated by commas, get a group direct message.
+func openDM(token stepconf.Secret, user string) (string, error) {
+	var response struct {
+		slack.APIResponse
+		Channel struct {
+			ID string `json:"id"`
+		} `json:"channel"`
+	}
+	if err := callAPI(token, "conversations.open", url.Values{"users": {user}}, &response); err != nil {
+		return "", err
+	}
+	return response.Channel.ID, nil
+}
+
+// isEmailList tells whether s is a comma separated list of email addresses.
+func isEmailList(s string) bool {
+	emails := strings.Split(s, ",")
+	for _, email := range emails {
+		if !isEmail(strings.TrimSpace(email)) {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveRecipients replaces a channel given as email addresses with a direct message
+// conversation to the users with those addresses.
+func resolveRecipients(c *config) error {
+	channel := strings.TrimSpace(c.Channel)
+	if !isEmailList(channel) {
+		return nil
+	}
+
+	var users []string
+	seen := map[string]bool{}
+	for _, email := range strings.Split(channel, ",") {
+		user, err := lookupUserByEmail(c.APIToken, email)
+		if err != nil {
+			return err
+		}
+		if !seen[user] {
+			seen[user] = true
+			users = append(users, user)
+		}
+	}
+
+	dm, err := openDM(c.APIToken, strings.Join(users, ","))
+	if err != nil {
+		return fmt.Errorf("failed to open a direct message with %s: %w", channel, err)
+	}
+	log.Infof("Sending a direct message to %s (%s)", channel, dm)
+	c.Channel = dm
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_resolveRecipients(t *testing.T) {
+	cache := userIDsByEmail
+	t.Cleanup(func() { userIDsByEmail = cache })
+	userIDsByEmail = map[string]string{}
+
+	lookups := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users.lookupByEmail":
+			lookups++
+			switch r.FormValue("email") {
+			case "dev@example.com":
+				fmt.Fprint(w, `{"ok": true, "user": {"id": "U1"}}`)
+			case "qa@example.com":
+				fmt.Fprint(w, `{"ok": true, "user": {"id": "U2", "deleted": true}}`)
+			default:
+				fmt.Fprint(w, `{"ok": false, "error": "users_not_found"}`)
+			}
+		case "/conversations.open":
+			fmt.Fprintf(w, `{"ok": true, "channel": {"id": "D-%s"}}`, r.FormValue("users"))
+		}
+	}))
+	defer server.Close()
+	defer func(u string) { slackAPIURL = u }(slackAPIURL)
+	slackAPIURL = server.URL
+
+	tests := []struct {
+		channel string
+		want    string
+		wantErr string
+	}{
+		{channel: "#general", want: "#general"},
+		{channel: "dev@example.com", want: "D-U1"},
+		{channel: "Dev@example.com, dev@example.com", want: "D-U1"},
+		{channel: "qa@example.com", wantErr: "deactivated"},
+		{channel: "nobody@example.com", wantErr: "no Slack user found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.channel, func(t *testing.T) {
+			c := config{APIToken: "token", Channel: tt.channel}
+			err := resolveRecipients(&c)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("resolveRecipients() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveRecipients() error = %v", err)
+			}
+			if c.Channel != tt.want {
+				t.Errorf("resolveRecipients() channel = %s, want %s", c.Channel, tt.want)
+			}
+		})
+	}
+
+	if lookups != 3 {
+		t.Errorf("users.lookupByEmail called %d times, want 3 (cached)", lookups)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+// postJSON posts the payload as JSON to a webhook and returns the response headers and body.
+// Unexpected status codes are classified as rejections, unless they point to another error class.
+func postJSON(url string, payload interface{}) (http.Header, []byte, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	log.Debugf("Request to webhook: %s\n", b)
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(b))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Add("Content-Type", "application/json; charset=utf-8")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, &classifiedError{Class: errorClassNetwork, Err: fmt.Errorf("failed to send the request: %s", err)}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.Header, nil, &classifiedError{Class: errorClassNetwork, Err: fmt.Errorf("server error: %s, failed to read response: %s", resp.Status, err)}
+	}
+	log.Debugf("Response from webhook: %s\n", body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.Header, body, statusError(resp.StatusCode, errorClassRejected, fmt.Errorf("server error: %s, response: %s", resp.Status, body))
+	}
+	return resp.Header, body, nil
+}
+
+var (
+	// slackLinkPattern matches the links of Slack's mrkdwn format: <url> and <url|text>.
+	slackLinkPattern = regexp.MustCompile(`<((?:https?|mailto):[^|>]+)(?:\|([^>]+))?>`)
+
+	// slackBoldPattern matches the bold text of Slack's mrkdwn format: *text*.
+	slackBoldPattern = regexp.MustCompile(`\*([^*\n]+)\*`)
+)
+
+// toMarkdown converts the links and bold text of Slack's mrkdwn format to Markdown.
+func toMarkdown(s string) string {
+	s = slackBoldPattern.ReplaceAllString(s, "**$1**")
+	return slackLinkPattern.ReplaceAllStringFunc(s, func(link string) string {
+		m := slackLinkPattern.FindStringSubmatch(link)
+		if m[2] == "" {
+			return m[1]
+		}
+		return fmt.Sprintf("[%s](%s)", m[2], m[1])
+	})
+}
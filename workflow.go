@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+)
+
+// workflowTriggerPrefix is the prefix of the URLs of Slack Workflow Builder webhook triggers.
+const workflowTriggerPrefix = "https://hooks.slack.com/triggers/"
+
+// isWorkflowTrigger tells whether the webhook URL triggers a Slack workflow instead of posting a message.
+func isWorkflowTrigger(webhookURL string) bool {
+	return strings.HasPrefix(strings.TrimSpace(webhookURL), workflowTriggerPrefix)
+}
+
+// workflowVariables returns the variables sent to a workflow trigger: the configured ones if any,
+// otherwise the build context and the texts of the message.
+func workflowVariables(conf config, msg slack.Message) map[string]string {
+	variables := map[string]string{}
+	if len(conf.WorkflowVariables) > 0 {
+		for _, p := range conf.WorkflowVariables {
+			variables[strings.TrimSpace(p[0])] = p[1]
+		}
+		return variables
+	}
+
+	status := "failure"
+	if conf.Success {
+		status = "success"
+	}
+	variables["status"] = status
+	variables["text"] = msg.Text
+	if len(msg.Attachments) > 0 {
+		variables["title"] = msg.Attachments[0].Title
+		variables["message"] = msg.Attachments[0].Text
+		variables["color"] = msg.Attachments[0].Color
+	}
+	variables["build_url"] = conf.BuildURL
+	variables["build_number"] = conf.BuildNumber
+	variables["app_slug"] = conf.AppSlug
+	variables["workflow"] = conf.WorkflowID
+	variables["branch"] = conf.GitBranch
+	return variables
+}
+
+// triggerWorkflow starts the Slack workflow with the variables instead of posting the message.
+func triggerWorkflow(webhookURL string, variables map[string]string) (slack.Response, error) {
+	_, body, err := postJSON(webhookURL, variables)
+	if err != nil {
+		return slack.Response{}, err
+	}
+
+	var response slack.Response
+	if err := json.Unmarshal(body, &response); err == nil && !response.OK && response.Error != "" {
+		return response, &slack.APIError{Method: "workflow trigger", Code: response.Error}
+	}
+	return slack.Response{OK: true}, nil
+}
+
+// workflowNotifier triggers the Slack workflow set as the webhook URL.
+type workflowNotifier struct {
+	webhookURL string
+	conf       config
+}
+
+// Notify implements Notifier.
+func (n workflowNotifier) Notify(msg slack.Message) (slack.Response, error) {
+	return triggerWorkflow(n.webhookURL, workflowVariables(n.conf, msg))
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/bitrise-steplib/steps-slack-message/slack"
+)
+
+func Test_workflowVariables(t *testing.T) {
+	msg := slack.Message{Text: "Build finished", Attachments: []slack.Attachment{{Title: "Success", Text: "All good", Color: "#3bc3a3"}}}
+
+	tests := []struct {
+		name string
+		conf config
+		want map[string]string
+	}{
+		{
+			name: "Build context and message",
+			conf: config{Success: true, BuildURL: "https://app.bitrise.io/build/1", BuildNumber: "42", AppSlug: "app", WorkflowID: "primary", GitBranch: "main"},
+			want: map[string]string{
+				"status":       "success",
+				"text":         "Build finished",
+				"title":        "Success",
+				"message":      "All good",
+				"color":        "#3bc3a3",
+				"build_url":    "https://app.bitrise.io/build/1",
+				"build_number": "42",
+				"app_slug":     "app",
+				"workflow":     "primary",
+				"branch":       "main",
+			},
+		},
+		{
+			name: "Configured variables",
+			conf: config{WorkflowVariables: pairs("release|1.2.0\n notes |Fixed the login")},
+			want: map[string]string{"release": "1.2.0", "notes": "Fixed the login"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := workflowVariables(tt.conf, msg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("workflowVariables() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_triggerWorkflow(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"ok": false, "error": "invalid_workflow_input"}`)
+	}))
+	defer server.Close()
+
+	_, err := triggerWorkflow(server.URL, map[string]string{"text": "hello"})
+	var apiErr *slack.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "invalid_workflow_input" {
+		t.Errorf("triggerWorkflow() error = %v, want invalid_workflow_input", err)
+	}
+
+	if !isWorkflowTrigger("https://hooks.slack.com/triggers/T000/123/abc") || isWorkflowTrigger("https://hooks.slack.com/services/T000/B000/XXXX") {
+		t.Errorf("isWorkflowTrigger() does not tell triggers from incoming webhooks")
+	}
+}